@@ -0,0 +1,95 @@
+package htracker
+
+import "fmt"
+
+// RecordBatch records one request for each of keys, duplicates included.
+// Keys are grouped by shard first so that each shard's lock is taken once
+// for the whole batch rather than once per key.
+func (ht *HotspotTrackerOf[K]) RecordBatch(keys []K) {
+	if len(keys) == 0 {
+		return
+	}
+
+	ht.resizeMu.RLock()
+	byShard := make([][]K, ht.numShards)
+	for _, key := range keys {
+		key = ht.normalizeKey(key)
+		ht.recordPrefix(key, 1)
+		idx := ht.shardIndex(key)
+		byShard[idx] = append(byShard[idx], key)
+	}
+	ht.totalRequests.Add(int64(len(keys)))
+	changes := ht.recordByShard(byShard, func(K) int { return 1 })
+	ht.resizeMu.RUnlock()
+
+	for _, change := range changes {
+		ht.notifyChange(change)
+	}
+}
+
+// AddCounts adds counts collected elsewhere, as if RecordRequestN had been
+// called for each entry, but taking each shard's lock once. Entries whose
+// keys normalize to the same key are summed. Counts of zero or less are
+// ignored, as by RecordRequestN, rather than subtracted.
+func (ht *HotspotTrackerOf[K]) AddCounts(counts map[K]int) {
+	normalized := make(map[K]int, len(counts))
+	var total int64
+	for key, n := range counts {
+		if n <= 0 {
+			continue
+		}
+		normalized[ht.normalizeKey(key)] += n
+		total += int64(n)
+	}
+	if len(normalized) == 0 {
+		return
+	}
+
+	ht.resizeMu.RLock()
+	byShard := make([][]K, ht.numShards)
+	for key, n := range normalized {
+		ht.recordPrefix(key, n)
+		idx := ht.shardIndex(key)
+		byShard[idx] = append(byShard[idx], key)
+	}
+	ht.totalRequests.Add(total)
+	changes := ht.recordByShard(byShard, func(key K) int { return normalized[key] })
+	ht.resizeMu.RUnlock()
+
+	for _, change := range changes {
+		ht.notifyChange(change)
+	}
+}
+
+// recordByShard records weight(key) requests for every key in byShard[i]
+// into shard i, holding each shard's lock once, and returns the heap
+// changes to notify once the locks are released. The caller must hold
+// resizeMu for reading.
+func (ht *HotspotTrackerOf[K]) recordByShard(byShard [][]K, weight func(K) int) []heapChange[K] {
+	var changes []heapChange[K]
+	for idx, shardKeys := range byShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+
+		s := ht.shards[idx]
+		var errs []error
+		s.lockRecord()
+		for _, key := range shardKeys {
+			change, err := s.recordLocked(key, weight(key))
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if !change.empty() {
+				change.shard = idx
+				changes = append(changes, change)
+			}
+		}
+		s.mu.Unlock()
+
+		for _, err := range errs {
+			ht.reportCorruption(fmt.Errorf("shard %d: %w", idx, err))
+		}
+	}
+	return changes
+}
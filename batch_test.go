@@ -0,0 +1,98 @@
+package htracker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRecordBatch(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	ht.RecordBatch(nil)
+	ht.RecordBatch([]string{})
+	if ht.TotalRequests() != 0 {
+		t.Errorf("expected empty batches to record nothing, got %d requests", ht.TotalRequests())
+	}
+
+	var admitted []string
+	ht.OnHotspot(func(key string, _ int) { admitted = append(admitted, key) })
+
+	ht.RecordBatch([]string{"a", "b", "a", "c", "a", "b", "d"})
+
+	expected := map[string]int{"a": 3, "b": 2, "c": 1, "d": 1}
+	for key, freq := range expected {
+		if got, _ := ht.GetFrequency(key); got != freq {
+			t.Errorf("expected %q to have frequency %d, got %d", key, freq, got)
+		}
+	}
+	if ht.TotalRequests() != 7 {
+		t.Errorf("expected 7 total requests, got %d", ht.TotalRequests())
+	}
+	if len(admitted) != 4 {
+		t.Errorf("expected OnHotspot for each of the 4 keys admitted to its shard, got %v", admitted)
+	}
+
+	// A batch matches the same requests recorded one by one
+	single := NewHotspotTracker(3, 4)
+	for _, key := range []string{"a", "b", "a", "c", "a", "b", "d"} {
+		single.RecordRequest(key)
+	}
+	assertKeyFreqs(t, ht.GetHotspotsWithCounts(), single.GetHotspotsWithCounts())
+}
+
+// BenchmarkRecordBatch compares recording a buffer of keys with RecordBatch
+// against a loop of RecordRequest calls.
+func BenchmarkRecordBatch(b *testing.B) {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i%200)
+	}
+
+	b.Run("loop", func(b *testing.B) {
+		ht := NewHotspotTracker(100, 8)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				ht.RecordRequest(key)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		ht := NewHotspotTracker(100, 8)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ht.RecordBatch(keys)
+		}
+	})
+}
+
+func TestAddCounts(t *testing.T) {
+	ht := NewHotspotTracker(3, 4).WithKeyNormalizer(strings.ToLower)
+	ht.RecordRequestN("a", 2)
+	ht.AddCounts(map[string]int{"a": 5, "B": 4, "b": 3, "c": 6, "d": 1, "neg": -5, "zero": 0})
+
+	expected := map[string]int{"a": 7, "b": 7, "c": 6, "d": 1}
+	for key, freq := range expected {
+		if got, _ := ht.GetFrequency(key); got != freq {
+			t.Errorf("expected %q to have frequency %d, got %d", key, freq, got)
+		}
+	}
+	for _, key := range []string{"neg", "zero"} {
+		if _, ok := ht.GetFrequency(key); ok {
+			t.Errorf("expected non-positive count for %q to be ignored", key)
+		}
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("expected hotspots [a b c], got %v", got)
+	}
+	if ht.TotalRequests() != 21 {
+		t.Errorf("expected 21 total requests, got %d", ht.TotalRequests())
+	}
+
+	ht.AddCounts(nil)
+	if ht.TotalRequests() != 21 {
+		t.Errorf("expected an empty map to record nothing, got %d requests", ht.TotalRequests())
+	}
+}
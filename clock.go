@@ -0,0 +1,34 @@
+package htracker
+
+import "time"
+
+// Clock is the source of time for the tracker's time-based features: the
+// cache refresh, windows, decay, leases and key TTLs
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C until it is stopped, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the default Clock, backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// WithClock makes the tracker read time from c instead of the system clock.
+// It must be called before any other option.
+func (ht *HotspotTrackerOf[K]) WithClock(c Clock) *HotspotTrackerOf[K] {
+	ht.clock = c
+	return ht
+}
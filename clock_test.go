@@ -0,0 +1,108 @@
+package htracker
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for time-dependent tests. Its
+// tickers fire when Advance moves past their next tick.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every ticker due by then.
+// Like time.Ticker, a ticker whose last tick is unread drops the new one.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.stopped || t.next.After(c.now) {
+			continue
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+// ticker registers a task on ht's ticker running every interval and
+// returns a function that advances c by interval and waits for that task
+// to run. Tasks run in registration order, so by then every task that was
+// due has run too.
+func (c *fakeClock) ticker(ht *HotspotTracker, interval time.Duration) func() {
+	var ticks atomic.Int64
+	ht.addTask(interval, func() { ticks.Add(1) })
+	return func() {
+		expected := ticks.Load() + 1
+		c.Advance(interval)
+		for ticks.Load() < expected {
+			runtime.Gosched()
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock   *fakeClock
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+func TestWithClockCacheRefresh(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(1, 1).WithClock(clock).WithCache(time.Second)
+	defer ht.Close()
+
+	ht.RecordRequestN("a", 2)
+	if got := ht.GetHotspots(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("expected [a], got %v", got)
+	}
+
+	ht.RecordRequestN("b", 5)
+	clock.Advance(time.Second / 2)
+	if got := ht.GetHotspots(); got[0] != "a" {
+		t.Fatalf("expected the cached [a] before the refresh interval, got %v", got)
+	}
+
+	clock.Advance(time.Second / 2)
+	for !ht.update.Load() {
+		runtime.Gosched()
+	}
+	if got := ht.GetHotspots(); got[0] != "b" {
+		t.Errorf("expected [b] after the cache refresh, got %v", got)
+	}
+}
@@ -0,0 +1,94 @@
+package htracker
+
+import "maps"
+
+// Clone returns a deep copy of the tracker's counts, taken with every
+// shard locked at once, so that GetHotspots, IsHotspot, GetFrequency
+// and the other reads on the clone all see the same instant. The clone
+// shares no KeyFreq with the receiver and runs no background goroutines:
+// it has no cache, window, decay, leases or key TTL, and nothing recorded
+// into either tracker afterwards shows up in the other. The clone keeps the
+// receiver's aggregation settings, WithMinFrequency, WithAdaptiveThreshold
+// and WithFullAggregation, and its sample rate, so it ranks and estimates
+// the copied counts the same way.
+func (ht *HotspotTrackerOf[K]) Clone() *HotspotTrackerOf[K] {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	// The write locks hold off the record fast path, which increments
+	// frequencies under the read lock and could otherwise reorder the heap
+	// mid-copy
+	for _, s := range ht.shards {
+		s.mu.Lock()
+	}
+	shards := make([]*shard[K], len(ht.shards))
+	for i, s := range ht.shards {
+		shards[i] = s.clone()
+	}
+	for _, s := range ht.shards {
+		s.mu.Unlock()
+	}
+
+	clone := &HotspotTrackerOf[K]{
+		shards:        shards,
+		numShards:     ht.numShards,
+		sharder:       ht.sharder,
+		newSharder:    ht.newSharder,
+		shardSalt:     ht.shardSalt,
+		hash:          ht.hash,
+		topN:          ht.topN,
+		keyTemplates:  ht.keyTemplates,
+		keyNormalizer: ht.keyNormalizer,
+		clock:         ht.clock,
+		logger:        ht.logger,
+
+		minFrequency:       ht.minFrequency,
+		adaptiveMultiplier: ht.adaptiveMultiplier,
+		fullAggregation:    ht.fullAggregation,
+		sampleEvery:        ht.sampleEvery,
+	}
+	clone.totalRequests.Store(ht.totalRequests.Load())
+	return clone
+}
+
+// clone copies the shard's counts. The caller must hold s.mu.
+func (s *shard[K]) clone() *shard[K] {
+	c := &shard[K]{
+		topN:       s.topN,
+		minHeap:    make(MinHeapOf[K], len(s.minHeap), cap(s.minHeap)),
+		keyFreqs:   make(map[K]*KeyFreqOf[K], len(s.keyFreqs)),
+		universe:   s.universe,
+		maxKeys:    s.maxKeys,
+		sketchHash: s.sketchHash,
+
+		floatWeights:    s.floatWeights,
+		minObservations: s.minObservations,
+	}
+	for key, kf := range s.keyFreqs {
+		copied := &KeyFreqOf[K]{Key: key, Frequency: kf.Frequency, Index: kf.Index, Weight: kf.Weight, FirstSeen: kf.FirstSeen, LastSeen: kf.LastSeen}
+		c.keyFreqs[key] = copied
+		if kf.Index >= 0 {
+			c.minHeap[kf.Index] = copied
+		}
+	}
+	if s.sketch != nil {
+		c.sketch = s.sketch.clone()
+	}
+	if s.history != nil {
+		c.history = s.history.clone()
+	}
+	if s.observations != nil {
+		c.observations = maps.Clone(s.observations)
+	}
+	c.keyCount.Store(int64(len(c.keyFreqs)))
+	c.maxFreq.Store(s.maxFreq.Load())
+	return c
+}
+
+// clone returns an independent copy of the sketch
+func (c *countMinSketch) clone() *countMinSketch {
+	rows := make([][]int, len(c.rows))
+	for i, row := range c.rows {
+		rows[i] = append([]int(nil), row...)
+	}
+	return &countMinSketch{width: c.width, rows: rows, total: c.total}
+}
@@ -0,0 +1,143 @@
+package htracker
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+	ht.RecordRequest("c")
+
+	clone := ht.Clone()
+
+	ht.RecordRequestN("c", 10)
+	ht.RecordRequestN("a", 2)
+	ht.RemoveKey("b")
+	ht.RecordRequest("d")
+
+	if got, expected := clone.GetHotspots(), []string{"a", "b"}; !slices.Equal(got, expected) {
+		t.Errorf("expected the clone's hotspots to stay %v, got %v", expected, got)
+	}
+	for key, expected := range map[string]int{"a": 5, "b": 3, "c": 1} {
+		if freq, ok := clone.GetFrequency(key); !ok || freq != expected {
+			t.Errorf("expected the clone to keep %q at %d, got (%d, %v)", key, expected, freq, ok)
+		}
+	}
+	if _, ok := clone.GetFrequency("d"); ok {
+		t.Error("expected 'd' recorded after cloning to be missing from the clone")
+	}
+	if clone.IsHotspot("c") || !clone.IsHotspot("b") {
+		t.Error("expected the clone's IsHotspot to agree with its GetHotspots")
+	}
+	if clone.TotalRequests() != 9 {
+		t.Errorf("expected the clone to keep 9 total requests, got %d", clone.TotalRequests())
+	}
+
+	// Recording into the clone leaves the original alone
+	clone.RecordRequestN("e", 100)
+	if _, ok := ht.GetFrequency("e"); ok {
+		t.Error("expected 'e' recorded into the clone to be missing from the original")
+	}
+	if got, expected := ht.GetHotspots(), []string{"c", "a"}; !slices.Equal(got, expected) {
+		t.Errorf("expected the original's hotspots to be %v, got %v", expected, got)
+	}
+}
+
+func TestCloneKeepsAggregationSettings(t *testing.T) {
+	record := func(ht *HotspotTracker) *HotspotTracker {
+		ht.RecordRequestN("a", 10)
+		ht.RecordRequestN("b", 4)
+		ht.RecordRequestN("c", 1)
+		return ht
+	}
+
+	t.Run("MinFrequency", func(t *testing.T) {
+		clone := record(NewHotspotTracker(3, 1).WithMinFrequency(4)).Clone()
+		if got, expected := clone.GetHotspots(), []string{"a", "b"}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("AdaptiveThreshold", func(t *testing.T) {
+		// The mean of the top 3 is 5
+		clone := record(NewHotspotTracker(3, 1).WithAdaptiveThreshold(1)).Clone()
+		if got, expected := clone.GetHotspots(), []string{"a"}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("FullAggregation", func(t *testing.T) {
+		clone := record(NewHotspotTracker(2, 1).WithFullAggregation()).Clone()
+		if !clone.fullAggregation {
+			t.Fatal("expected the clone to keep full aggregation")
+		}
+		// Only full aggregation sees past a heap that is out of step
+		s := clone.shards[0]
+		heap.Remove(&s.minHeap, s.keyFreqs["b"].Index)
+		if got, expected := clone.GetHotspots(), []string{"a", "b"}; !slices.Equal(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("SampleRate", func(t *testing.T) {
+		// AddCounts is not sampled, so the receiver has counts to copy
+		ht := NewHotspotTracker(3, 1).WithSampleRate(0.1)
+		ht.AddCounts(map[string]int{"a": 10, "b": 4})
+		estimates := ht.Clone().GetHotspotsWithCI()
+		if len(estimates) != 2 {
+			t.Fatalf("expected 2 estimates, got %+v", estimates)
+		}
+		for _, est := range estimates {
+			if est.LowerBound == est.UpperBound {
+				t.Errorf("expected sampling variance for %q, got %+v", est.Key, est)
+			}
+		}
+	})
+}
+
+// TestCloneConcurrent clones while requests for heap keys take the record
+// fast path. Run it with -race.
+func TestCloneConcurrent(t *testing.T) {
+	ht := NewHotspotTracker(1024, 1)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		ht.RecordRequestN(keys[i], 2)
+	}
+	// Cold keys outside the heap draw out each copy
+	for i := 0; i < 10000; i++ {
+		ht.RecordRequest(fmt.Sprintf("cold%d", i))
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				ht.RecordRequest(keys[rand.IntN(len(keys))])
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if err := ht.Clone().Validate(); err != nil {
+			t.Errorf("clone %d: %v", i, err)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package htracker
+
+import "slices"
+
+// GetColdspots returns the n least frequent keys, least frequent first, in
+// the exact reverse of GetHotspots' order. By default these are the
+// coldest of the aggregated top N, not the coldest keys seen: keys outside
+// every shard's top N are not considered. With WithFullAggregation every
+// tracked key is, which makes the result the globally coldest keys at the
+// cost of sorting them all.
+func (ht *HotspotTrackerOf[K]) GetColdspots(n int) []K {
+	if n <= 0 {
+		return []K{}
+	}
+	if ht.fullAggregation {
+		return ht.coldestTracked(n)
+	}
+
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	// The aggregate's heap is in ascending rank order
+	n = min(n, len(aggregateShard.minHeap))
+	keys := make([]K, n)
+	for i := range keys {
+		keys[i] = aggregateShard.minHeap[i].Key
+	}
+	return keys
+}
+
+// coldestTracked returns the n lowest ranked keys of every shard
+func (ht *HotspotTrackerOf[K]) coldestTracked(n int) []K {
+	var all []KeyFreqOf[K]
+	ht.resizeMu.RLock()
+	for _, s := range ht.shards {
+		s.expire()
+		s.mu.RLock()
+		for key, kf := range s.keyFreqs {
+			all = append(all, KeyFreqOf[K]{Key: key, Frequency: loadFrequency(&kf.Frequency), Weight: kf.Weight})
+		}
+		s.mu.RUnlock()
+	}
+	ht.resizeMu.RUnlock()
+
+	slices.SortFunc(all, func(a, b KeyFreqOf[K]) int { return compareRank(&b, &a) })
+	n = min(n, len(all))
+	keys := make([]K, n)
+	for i := range keys {
+		keys[i] = all[i].Key
+	}
+	return keys
+}
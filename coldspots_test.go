@@ -0,0 +1,35 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetColdspots(t *testing.T) {
+	freqs := map[string]int{"a": 9, "b": 7, "c": 5, "d": 5, "e": 3, "f": 1, "g": 2}
+
+	ht := NewHotspotTracker(5, 1)
+	for key, n := range freqs {
+		ht.RecordRequestN(key, n)
+	}
+	// The top 5 are a, b, c, d and e, so f and g aren't considered
+	if got := ht.GetColdspots(3); !slices.Equal(got, []string{"e", "d", "c"}) {
+		t.Errorf("expected [e d c], got %v", got)
+	}
+	hotspots := ht.GetHotspots()
+	slices.Reverse(hotspots)
+	if got := ht.GetColdspots(10); !slices.Equal(got, hotspots) {
+		t.Errorf("expected the reverse of the hotspots %v, got %v", hotspots, got)
+	}
+	if got := ht.GetColdspots(0); len(got) != 0 {
+		t.Errorf("expected no coldspots for n=0, got %v", got)
+	}
+
+	full := NewHotspotTracker(5, 4).WithFullAggregation()
+	for key, n := range freqs {
+		full.RecordRequestN(key, n)
+	}
+	if got := full.GetColdspots(3); !slices.Equal(got, []string{"f", "g", "e"}) {
+		t.Errorf("expected every tracked key to be considered, [f g e], got %v", got)
+	}
+}
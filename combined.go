@@ -0,0 +1,94 @@
+package htracker
+
+import "sort"
+
+// CombinedHotspotOf describes a key's standing in both trackers of a
+// CombinedTrackerOf. A rank of 0 means the key is not a hotspot in that
+// tracker.
+type CombinedHotspotOf[K comparable] struct {
+	Key            K
+	ShortRank      int
+	ShortFrequency int
+	LongRank       int
+	LongFrequency  int
+}
+
+// CombinedHotspot is a CombinedHotspotOf string keys
+type CombinedHotspot = CombinedHotspotOf[string]
+
+// CombinedTrackerOf composes a short-term, high resolution tracker with a
+// long-term baseline tracker so that currently hot keys can be told apart
+// from historically hot ones in a single query.
+type CombinedTrackerOf[K comparable] struct {
+	short *HotspotTrackerOf[K]
+	long  *HotspotTrackerOf[K]
+}
+
+// CombinedTracker is a CombinedTrackerOf string keys
+type CombinedTracker = CombinedTrackerOf[string]
+
+// NewCombinedTracker combines two independently configured trackers.
+// Typically short uses a small window and long a slow decay.
+func NewCombinedTracker(short, long *HotspotTracker) *CombinedTracker {
+	return NewCombinedTrackerOf(short, long)
+}
+
+// NewCombinedTrackerOf is NewCombinedTracker for trackers of keys of type K
+func NewCombinedTrackerOf[K comparable](short, long *HotspotTrackerOf[K]) *CombinedTrackerOf[K] {
+	return &CombinedTrackerOf[K]{short: short, long: long}
+}
+
+// RecordRequest records a request with a given key in both trackers
+func (ct *CombinedTrackerOf[K]) RecordRequest(key K) {
+	ct.short.RecordRequest(key)
+	ct.long.RecordRequest(key)
+}
+
+// GetHotspotsCombined returns every key that is a hotspot in either tracker
+// with its rank and frequency in each. Keys that are hot short-term come
+// first in short-term rank order, followed by keys only hot long-term.
+func (ct *CombinedTrackerOf[K]) GetHotspotsCombined() []CombinedHotspotOf[K] {
+	byKey := make(map[K]*CombinedHotspotOf[K])
+	var combined []*CombinedHotspotOf[K]
+
+	get := func(key K) *CombinedHotspotOf[K] {
+		ch, exists := byKey[key]
+		if !exists {
+			ch = &CombinedHotspotOf[K]{Key: key}
+			byKey[key] = ch
+			combined = append(combined, ch)
+		}
+		return ch
+	}
+
+	short := ct.short.aggregate()
+	defer ct.short.releaseAggregate(short)
+	long := ct.long.aggregate()
+	defer ct.long.releaseAggregate(long)
+
+	for i, kf := range short.sortedKeyFreqs() {
+		ch := get(kf.Key)
+		ch.ShortRank, ch.ShortFrequency = i+1, kf.Frequency
+	}
+	for i, kf := range long.sortedKeyFreqs() {
+		ch := get(kf.Key)
+		ch.LongRank, ch.LongFrequency = i+1, kf.Frequency
+	}
+
+	sort.SliceStable(combined, func(i, j int) bool {
+		a, b := combined[i], combined[j]
+		if (a.ShortRank == 0) != (b.ShortRank == 0) {
+			return a.ShortRank != 0
+		}
+		if a.ShortRank != 0 {
+			return a.ShortRank < b.ShortRank
+		}
+		return a.LongRank < b.LongRank
+	})
+
+	result := make([]CombinedHotspotOf[K], len(combined))
+	for i, ch := range combined {
+		result[i] = *ch
+	}
+	return result
+}
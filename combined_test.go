@@ -0,0 +1,57 @@
+package htracker
+
+import "testing"
+
+func TestCombinedTracker(t *testing.T) {
+	short := NewHotspotTracker(2, 2)
+	long := NewHotspotTracker(2, 2)
+
+	// "legacy" was hot long ago and is only known to the long-term tracker
+	for i := 0; i < 50; i++ {
+		long.RecordRequest("legacy")
+	}
+
+	ct := NewCombinedTracker(short, long)
+	for i := 0; i < 10; i++ {
+		ct.RecordRequest("trending")
+	}
+	ct.RecordRequest("other")
+
+	combined := ct.GetHotspotsCombined()
+	expected := []CombinedHotspot{
+		{Key: "trending", ShortRank: 1, ShortFrequency: 10, LongRank: 2, LongFrequency: 10},
+		{Key: "other", ShortRank: 2, ShortFrequency: 1},
+		{Key: "legacy", LongRank: 1, LongFrequency: 50},
+	}
+	if len(combined) != len(expected) {
+		t.Fatalf("expected %d combined hotspots, got %v", len(expected), combined)
+	}
+	for i := range expected {
+		if combined[i] != expected[i] {
+			t.Errorf("position %d: expected %+v, got %+v", i, expected[i], combined[i])
+		}
+	}
+}
+
+func TestCombinedTrackerOf(t *testing.T) {
+	hash := func(k int) uint32 { return uint32(k) }
+	short, long := NewHotspotTrackerOf(1, 1, hash), NewHotspotTrackerOf(2, 1, hash)
+	long.RecordRequestN(7, 5)
+
+	ct := NewCombinedTrackerOf(short, long)
+	ct.RecordRequest(3)
+
+	expected := []CombinedHotspotOf[int]{
+		{Key: 3, ShortRank: 1, ShortFrequency: 1, LongRank: 2, LongFrequency: 1},
+		{Key: 7, LongRank: 1, LongFrequency: 5},
+	}
+	combined := ct.GetHotspotsCombined()
+	if len(combined) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, combined)
+	}
+	for i := range expected {
+		if combined[i] != expected[i] {
+			t.Errorf("position %d: expected %+v, got %+v", i, expected[i], combined[i])
+		}
+	}
+}
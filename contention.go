@@ -0,0 +1,92 @@
+package htracker
+
+import "time"
+
+// Contention thresholds for AutoTune, as the average number of requests
+// waiting for a shard lock on the record path
+const (
+	highContention = 0.1
+	lowContention  = 0.001
+)
+
+// ShardContention counts how often recording found a shard's lock held,
+// and how long it waited for the lock in total
+type ShardContention struct {
+	Contended int64
+	Wait      time.Duration
+}
+
+// ShardContention returns the lock counts of each shard since it was
+// created, indexed by shard. Resize starts the counts over.
+func (ht *HotspotTrackerOf[K]) ShardContention() []ShardContention {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+
+	counts := make([]ShardContention, len(ht.shards))
+	for i, s := range ht.shards {
+		counts[i] = ShardContention{
+			Contended: s.contended.Load(),
+			Wait:      time.Duration(s.lockWait.Load()),
+		}
+	}
+	return counts
+}
+
+// AutoTune sleeps for sampleDuration while watching how long recording
+// waits for shard locks, and suggests a shard count from the average
+// number of requests waiting at a time: twice the current count above
+// 0.1, half of it below 0.001, and the current one otherwise or if nothing
+// was recorded. It only suggests; apply the count with Resize. Since
+// contention only shows while requests are recorded, call it under
+// representative load. It measures real time, whatever the tracker's
+// clock.
+func (ht *HotspotTrackerOf[K]) AutoTune(sampleDuration time.Duration) int {
+	before, startRequests := ht.ShardContention(), ht.totalRequests.Load()
+	start := time.Now()
+	time.Sleep(sampleDuration)
+	after, requests := ht.ShardContention(), ht.totalRequests.Load()-startRequests
+	elapsed := time.Since(start)
+
+	ht.resizeMu.RLock()
+	numShards := ht.numShards
+	ht.resizeMu.RUnlock()
+	if requests == 0 || len(before) != len(after) {
+		return numShards
+	}
+	var wait time.Duration
+	for i := range after {
+		wait += after[i].Wait - before[i].Wait
+	}
+	// A Resize during the sample reset the counts
+	if wait < 0 {
+		return numShards
+	}
+	switch waiting := float64(wait) / float64(elapsed); {
+	case waiting > highContention:
+		return numShards * 2
+	case waiting < lowContention:
+		return max(numShards/2, 1)
+	}
+	return numShards
+}
+
+// lockRecord takes the shard's write lock for recording, counting whether
+// and how long it had to wait. Only a contended acquisition reads the time.
+func (s *shard[K]) lockRecord() {
+	if !s.mu.TryLock() {
+		start := time.Now()
+		s.mu.Lock()
+		s.contended.Add(1)
+		s.lockWait.Add(int64(time.Since(start)))
+	}
+}
+
+// rlockRecord is lockRecord for the read lock of the record fast path
+func (s *shard[K]) rlockRecord() {
+	if !s.mu.TryRLock() {
+		start := time.Now()
+		s.mu.RLock()
+		s.contended.Add(1)
+		s.lockWait.Add(int64(time.Since(start)))
+	}
+}
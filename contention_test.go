@@ -0,0 +1,98 @@
+package htracker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardContention(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordBatch([]string{"a", "b", "c"})
+	for i := 0; i < 10; i++ {
+		ht.RecordRequest("a")
+	}
+	for _, c := range ht.ShardContention() {
+		if c != (ShardContention{}) {
+			t.Errorf("expected no contention from a single goroutine, got %+v", c)
+		}
+	}
+
+	s := ht.shards[ht.shardIndex("a")]
+	s.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		ht.RecordRequest("a")
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Unlock()
+	<-done
+
+	c := ht.ShardContention()[ht.shardIndex("a")]
+	if c.Contended != 1 || c.Wait < 5*time.Millisecond {
+		t.Errorf("expected one wait of about 10ms, got %+v", c)
+	}
+}
+
+func TestAutoTune(t *testing.T) {
+	t.Run("contended", func(t *testing.T) {
+		ht := NewHotspotTracker(5, 2)
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+
+		// Hold both shards' locks most of the time, as a slow critical
+		// section would
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, s := range ht.shards {
+					s.mu.Lock()
+				}
+				time.Sleep(100 * time.Microsecond)
+				for _, s := range ht.shards {
+					s.mu.Unlock()
+				}
+			}
+		}()
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						ht.RecordRequest("k")
+					}
+				}
+			}()
+		}
+
+		got := ht.AutoTune(50 * time.Millisecond)
+		close(stop)
+		wg.Wait()
+		if got != 4 {
+			t.Errorf("expected heavy contention to suggest 4 shards, got %d", got)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		ht := NewHotspotTracker(5, 8)
+		done := make(chan int)
+		go func() { done <- ht.AutoTune(20 * time.Millisecond) }()
+		for i := 0; i < 1000; i++ {
+			ht.RecordRequest("k")
+		}
+		if got := <-done; got != 4 && got != 8 {
+			t.Errorf("expected no contention to suggest 4 shards, or 8 if the sample missed every request, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package htracker
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// WithPairTracking also counts every pair of distinct keys recorded
+// together by RecordCooccurrence, read with GetHotPairs. Pairs are kept in
+// a single map, so only use it with a bounded set of keys. It must be
+// called before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithPairTracking() *HotspotTrackerOf[K] {
+	ht.pairs = &pairCounts[K]{counts: make(map[[2]K]int)}
+	return ht
+}
+
+// GetHotPairs returns the topN pairs of keys most often recorded together,
+// most frequent first, with ties broken by key. Each pair is ordered by
+// key. It returns nil without WithPairTracking.
+func (ht *HotspotTrackerOf[K]) GetHotPairs() [][2]K {
+	if ht.pairs == nil {
+		return nil
+	}
+	return ht.pairs.top(ht.topN)
+}
+
+// pairCounts counts pairs of keys, each ordered by key
+type pairCounts[K comparable] struct {
+	mu     sync.Mutex
+	counts map[[2]K]int
+}
+
+// record counts n occurrences of every pair of distinct keys
+func (p *pairCounts[K]) record(distinct []K, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, a := range distinct {
+		for _, b := range distinct[i+1:] {
+			if compareKeys(a, b) > 0 {
+				p.counts[[2]K{b, a}] += n
+			} else {
+				p.counts[[2]K{a, b}] += n
+			}
+		}
+	}
+}
+
+// top returns the n most frequent pairs
+func (p *pairCounts[K]) top(n int) [][2]K {
+	p.mu.Lock()
+	all := make([]KeyFreqOf[[2]K], 0, len(p.counts))
+	for pair, freq := range p.counts {
+		all = append(all, KeyFreqOf[[2]K]{Key: pair, Frequency: freq})
+	}
+	p.mu.Unlock()
+
+	slices.SortFunc(all, func(a, b KeyFreqOf[[2]K]) int {
+		if c := cmp.Compare(b.Frequency, a.Frequency); c != 0 {
+			return c
+		}
+		if c := compareKeys(a.Key[0], b.Key[0]); c != 0 {
+			return c
+		}
+		return compareKeys(a.Key[1], b.Key[1])
+	})
+	pairs := make([][2]K, min(n, len(all)))
+	for i := range pairs {
+		pairs[i] = all[i].Key
+	}
+	return pairs
+}
+
+// RecordCooccurrence records one request for each of keys as a single
+// operation, for keys that occurred together, such as the items of one
+// order. Every shard involved is locked at once while the keys are
+// counted, and aggregations are held off meanwhile, so GetHotspots, Clone
+// and the other aggregate reads see either all of the increments or none.
+// Each distinct key is counted once however often it is listed. With
+// WithPairTracking every pair of distinct keys is counted too.
+func (ht *HotspotTrackerOf[K]) RecordCooccurrence(keys ...K) {
+	weight, ok := ht.sample()
+	if len(keys) == 0 || !ok {
+		return
+	}
+	distinct := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if key = ht.normalizeKey(key); !slices.Contains(distinct, key) {
+			distinct = append(distinct, key)
+		}
+	}
+
+	ht.cooccurMu.Lock()
+	ht.resizeMu.RLock()
+	byShard := make([][]K, ht.numShards)
+	for _, key := range distinct {
+		idx := ht.shardIndex(key)
+		byShard[idx] = append(byShard[idx], key)
+	}
+	// Shards are locked in index order, so concurrent calls can't deadlock
+	for idx, shardKeys := range byShard {
+		if len(shardKeys) > 0 {
+			ht.shards[idx].lockRecord()
+		}
+	}
+	ht.totalRequests.Add(int64(len(distinct) * weight))
+	var changes []heapChange[K]
+	var errs []error
+	for idx, shardKeys := range byShard {
+		for _, key := range shardKeys {
+			change, err := ht.shards[idx].recordLocked(key, weight)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("shard %d: %w", idx, err))
+			}
+			if !change.empty() {
+				change.shard = idx
+				changes = append(changes, change)
+			}
+		}
+	}
+	for idx, shardKeys := range byShard {
+		if len(shardKeys) > 0 {
+			ht.shards[idx].mu.Unlock()
+		}
+	}
+	ht.resizeMu.RUnlock()
+	ht.cooccurMu.Unlock()
+
+	for _, err := range errs {
+		ht.reportCorruption(err)
+	}
+	for _, change := range changes {
+		ht.notifyChange(change)
+	}
+	for _, key := range distinct {
+		ht.recordPrefix(key, weight)
+	}
+	if ht.pairs != nil {
+		ht.pairs.record(distinct, weight)
+	}
+}
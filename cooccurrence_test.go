@@ -0,0 +1,84 @@
+package htracker
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRecordCooccurrence(t *testing.T) {
+	ht := NewHotspotTracker(5, 4).WithPairTracking()
+
+	for i := 0; i < 3; i++ {
+		ht.RecordCooccurrence("cart", "checkout", "cart", "pay")
+		ht.RecordCooccurrence("cart", "search")
+	}
+	ht.RecordCooccurrence()
+
+	for key, want := range map[string]int{"cart": 6, "checkout": 3, "pay": 3, "search": 3} {
+		if freq, _ := ht.GetFrequency(key); freq != want {
+			t.Errorf("expected %q to have frequency %d, got %d", key, want, freq)
+		}
+	}
+	if total := ht.TotalRequests(); total != 15 {
+		t.Errorf("expected 15 requests, counting each distinct key once, got %d", total)
+	}
+
+	want := [][2]string{{"cart", "checkout"}, {"cart", "pay"}, {"cart", "search"}, {"checkout", "pay"}}
+	if got := ht.GetHotPairs(); !slices.Equal(got, want) {
+		t.Errorf("expected pairs %v, got %v", want, got)
+	}
+	if NewHotspotTracker(1, 1).GetHotPairs() != nil {
+		t.Error("expected no pairs without WithPairTracking")
+	}
+}
+
+func TestRecordCooccurrenceIsAtomic(t *testing.T) {
+	ht := NewHotspotTracker(4, 8)
+
+	// Pick keys in different shards
+	keys := []string{"k0"}
+	for i := 1; len(keys) < 3; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if !slices.ContainsFunc(keys, func(k string) bool { return ht.shardIndex(k) == ht.shardIndex(key) }) {
+			keys = append(keys, key)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for w := 0; w < 2; w++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				ht.RecordCooccurrence(keys...)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		counts := ht.GetHotspotsWithCounts()
+		if len(counts) != 0 && len(counts) != 3 {
+			t.Fatalf("aggregation observed a partial co-occurrence: %v", counts)
+		}
+		for _, kf := range counts {
+			if kf.Frequency != counts[0].Frequency {
+				t.Fatalf("aggregation observed a partial co-occurrence: %v", counts)
+			}
+		}
+		select {
+		case <-done:
+			if counts := ht.GetHotspotsWithCounts(); len(counts) != 3 || counts[0].Frequency != 1000 {
+				t.Errorf("expected 3 keys at 1000, got %v", counts)
+			}
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package htracker
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrCorrupted is wrapped by every error reported through OnCorruption.
+var ErrCorrupted = errors.New("htracker: heap corruption detected")
+
+// WithCorruptionDetection enables cheap invariant spot-checks on the record
+// path. A shard found to be corrupted is rebuilt from its key map instead of
+// silently serving wrong results, and the event is counted.
+func (ht *HotspotTrackerOf[K]) WithCorruptionDetection() *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.checkInvariants = true
+	}
+	return ht
+}
+
+// OnCorruption registers fn to be called, outside any shard lock, every time
+// corruption is detected and repaired.
+func (ht *HotspotTrackerOf[K]) OnCorruption(fn func(error)) *HotspotTrackerOf[K] {
+	ht.onCorruption = fn
+	return ht
+}
+
+// CorruptionEvents returns the number of corruptions detected and repaired so far
+func (ht *HotspotTrackerOf[K]) CorruptionEvents() int64 {
+	return ht.corruptionEvents.Load()
+}
+
+func (ht *HotspotTrackerOf[K]) reportCorruption(err error) {
+	ht.corruptionEvents.Add(1)
+	ht.recordEvent(ReasonCorruption, err.Error())
+	if ht.onCorruption != nil {
+		ht.onCorruption(err)
+	}
+}
+
+// Validate checks every shard's heap and key map against each other and
+// returns an error wrapping ErrCorrupted describing the first violation
+// found, or nil. It is a full, slow check meant for tests: each shard is
+// write-locked while it is checked.
+func (ht *HotspotTrackerOf[K]) Validate() error {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	for i, s := range ht.shards {
+		s.mu.Lock()
+		err := s.validate()
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the heap is within its bound and ordered, that every
+// heap entry is the key map's entry for its key at the index it claims, and
+// that every key outside the heap says so. The caller must hold s.mu.
+func (s *shard[K]) validate() error {
+	if err := s.checkRoot(); err != nil {
+		return err
+	}
+	for i, kf := range s.minHeap {
+		if kf == nil {
+			return fmt.Errorf("%w: heap entry %d is nil", ErrCorrupted, i)
+		}
+		if s.keyFreqs[kf.Key] != kf {
+			return fmt.Errorf("%w: heap entry %d, key %#v, is not in the key map", ErrCorrupted, i, kf.Key)
+		}
+		if kf.Index != i {
+			return fmt.Errorf("%w: key %#v is at heap index %d but claims %d", ErrCorrupted, kf.Key, i, kf.Index)
+		}
+		if parent := (i - 1) / 2; i > 0 && s.minHeap.Less(i, parent) {
+			return fmt.Errorf("%w: key %#v ranks below its parent %#v", ErrCorrupted, kf.Key, s.minHeap[parent].Key)
+		}
+	}
+	for key, kf := range s.keyFreqs {
+		if kf.Key != key {
+			return fmt.Errorf("%w: key %#v is stored under %#v", ErrCorrupted, kf.Key, key)
+		}
+		if kf.Index >= 0 {
+			if err := s.checkIndex(kf); err != nil {
+				return err
+			}
+		} else if kf.Index != -1 {
+			return fmt.Errorf("%w: key %#v has invalid index %d", ErrCorrupted, key, kf.Index)
+		}
+	}
+	if n := s.keyCount.Load(); n != int64(len(s.keyFreqs)) {
+		return fmt.Errorf("%w: key count is %d, key map holds %d", ErrCorrupted, n, len(s.keyFreqs))
+	}
+	return nil
+}
+
+// checkIndex verifies that kf sits in the heap at the position it claims.
+func (s *shard[K]) checkIndex(kf *KeyFreqOf[K]) error {
+	if kf.Index < 0 || kf.Index >= len(s.minHeap) || s.minHeap[kf.Index] != kf {
+		return fmt.Errorf("%w: key %#v has stale index %d", ErrCorrupted, kf.Key, kf.Index)
+	}
+	return nil
+}
+
+// checkRoot verifies the heap is within its bound and that the root is not
+// greater than its children. It is deliberately not a full heap validation.
+func (s *shard[K]) checkRoot() error {
+	if len(s.minHeap) > s.topN {
+		return fmt.Errorf("%w: heap has %d entries, limit is %d", ErrCorrupted, len(s.minHeap), s.topN)
+	}
+	for _, child := range []int{1, 2} {
+		if child < len(s.minHeap) && s.minHeap[child].Frequency < s.minHeap[0].Frequency {
+			return fmt.Errorf("%w: root %#v is greater than child %#v", ErrCorrupted, s.minHeap[0].Key, s.minHeap[child].Key)
+		}
+	}
+	return nil
+}
+
+// rebuild restores the heap from keyFreqs, which is treated as the source of
+// truth, by selecting its top N eligible keys afresh, and resets maxFreq to
+// the heap's maximum.
+func (s *shard[K]) rebuild() {
+	all := make([]*KeyFreqOf[K], 0, len(s.keyFreqs))
+	for _, kf := range s.keyFreqs {
+		kf.Index = -1
+		if s.eligible(kf.Key) {
+			all = append(all, kf)
+		}
+	}
+	slices.SortFunc(all, compareRank[K])
+	if len(all) > s.topN {
+		all = all[:s.topN]
+	}
+
+	s.minHeap = s.minHeap[:0]
+	for _, kf := range all {
+		kf.Index = len(s.minHeap)
+		s.minHeap = append(s.minHeap, kf)
+	}
+	heap.Init(&s.minHeap)
+
+	maxFreq := 0
+	for _, kf := range s.minHeap {
+		maxFreq = max(maxFreq, kf.Frequency)
+	}
+	s.maxFreq.Store(int64(maxFreq))
+}
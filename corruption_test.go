@@ -0,0 +1,114 @@
+package htracker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCorruptionDetection(t *testing.T) {
+	var reported []error
+	ht := NewHotspotTracker(3, 1).WithCorruptionDetection().OnCorruption(func(err error) {
+		reported = append(reported, err)
+	})
+
+	keys := []string{"a", "a", "a", "b", "b", "c"}
+	for _, key := range keys {
+		ht.RecordRequest(key)
+	}
+
+	// Corrupt the shard by giving "a" a stale heap index
+	s := ht.shards[0]
+	s.keyFreqs["a"].Index = 7
+
+	ht.RecordRequest("a")
+
+	if ht.CorruptionEvents() != 1 {
+		t.Fatalf("expected 1 corruption event, got %d", ht.CorruptionEvents())
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrCorrupted) {
+		t.Fatalf("expected one ErrCorrupted report, got %v", reported)
+	}
+
+	for i, kf := range s.minHeap {
+		if kf.Index != i {
+			t.Errorf("key %q has index %d, expected %d after rebuild", kf.Key, kf.Index, i)
+		}
+	}
+	if s.keyFreqs["a"].Frequency != 4 {
+		t.Errorf("expected 'a' to have frequency 4, got %d", s.keyFreqs["a"].Frequency)
+	}
+
+	// Break the heap ordering directly and let the root check catch it
+	s.minHeap[0].Frequency = 100
+	ht.RecordRequest("d")
+	if ht.CorruptionEvents() != 2 {
+		t.Fatalf("expected 2 corruption events, got %d", ht.CorruptionEvents())
+	}
+	if s.minHeap[0].Frequency == 100 {
+		t.Errorf("expected heap root to be repaired, got %q", s.minHeap[0].Key)
+	}
+}
+
+func TestCorruptionDetectionDisabled(t *testing.T) {
+	ht := NewHotspotTracker(3, 1)
+	for _, key := range []string{"a", "b", "c", "a"} {
+		ht.RecordRequest(key)
+	}
+	if ht.CorruptionEvents() != 0 {
+		t.Errorf("expected no corruption events, got %d", ht.CorruptionEvents())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(s *shard[string])
+	}{
+		{"stale index", func(s *shard[string]) { s.keyFreqs["a"].Index = 7 }},
+		{"heap order", func(s *shard[string]) { s.minHeap[0].Frequency = 100 }},
+		{"swapped entries", func(s *shard[string]) { s.minHeap[0], s.minHeap[1] = s.minHeap[1], s.minHeap[0] }},
+		{"key missing from map", func(s *shard[string]) { delete(s.keyFreqs, s.minHeap[0].Key) }},
+		{"key outside heap claims a slot", func(s *shard[string]) { s.keyFreqs["d"].Index = 0 }},
+		{"invalid index", func(s *shard[string]) { s.keyFreqs["d"].Index = -2 }},
+		{"wrong key", func(s *shard[string]) { s.keyFreqs["d"].Key = "e" }},
+		{"key count", func(s *shard[string]) { s.keyCount.Add(1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ht := NewHotspotTracker(3, 2)
+			ht.AddCounts(map[string]int{"x": 1})
+			s := ht.shards[ht.shardIndex("a")]
+			for _, key := range []string{"a", "a", "a", "b", "b", "c", "d"} {
+				s.RecordRequest(key)
+			}
+			if err := ht.Validate(); err != nil {
+				t.Fatalf("expected a valid tracker before corruption, got %v", err)
+			}
+
+			tt.corrupt(s)
+			err := ht.Validate()
+			if !errors.Is(err, ErrCorrupted) {
+				t.Errorf("expected ErrCorrupted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateAfterOperations(t *testing.T) {
+	ht := NewHotspotTracker(4, 4).WithMaxKeysPerShard(40)
+	for i := 0; i < 2000; i++ {
+		ht.RecordRequestN(fmt.Sprintf("key%d", i%300), i%13+1)
+		if i%100 == 0 {
+			ht.RemoveKey(fmt.Sprintf("key%d", i%50))
+		}
+	}
+	ht.PruneBelow(20)
+	if err := ht.Resize(3); err != nil {
+		t.Fatal(err)
+	}
+	ht.Compact()
+	if err := ht.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,96 @@
+package htracker
+
+import (
+	"math"
+	"time"
+)
+
+// decayStepsPerHalfLife is how often decay is applied within one half-life
+const decayStepsPerHalfLife = 10
+
+// WithDecay makes frequencies decay exponentially so that recent requests
+// weigh more than old ones: a request counts half as much after halfLife,
+// a quarter after two, and so on. Decay is applied to each shard every
+// halfLife/10 from the tracker's ticker, which Close stops. Keys whose
+// decayed frequency rounds down to zero are dropped. It must be called
+// before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithDecay(halfLife time.Duration) *HotspotTrackerOf[K] {
+	now := ht.clock.Now()
+	for _, s := range ht.shards {
+		s.weights = make(map[K]float64)
+		s.decayedAt = now
+	}
+	ht.halfLife = halfLife
+	ht.checkFloatWeights()
+	ht.checkSketch()
+	return ht.withPeriodicTask(halfLife/decayStepsPerHalfLife, ht.decayShard)
+}
+
+// WithLazyDecay decays frequencies like WithDecay but without a background
+// goroutine, for short-lived processes: each shard catches up on the decay
+// it owes when it is next recorded into or read, once at least halfLife/10
+// has passed since it was last decayed. Concurrent readers cannot decay a
+// shard twice, since the elapsed time is measured and reset under the
+// shard's lock. It must be called before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithLazyDecay(halfLife time.Duration) *HotspotTrackerOf[K] {
+	now := ht.clock.Now()
+	for _, s := range ht.shards {
+		s.weights = make(map[K]float64)
+		s.decayedAt = now
+		s.lazyHalfLife = halfLife
+		s.now = ht.clock.Now
+	}
+	ht.halfLife = halfLife
+	ht.checkFloatWeights()
+	ht.checkSketch()
+	return ht
+}
+
+func (ht *HotspotTrackerOf[K]) decayShard(s *shard[K]) {
+	s.decay(ht.halfLife, ht.clock.Now())
+}
+
+// decay scales the shard's weights by the decay accumulated between the
+// last time it was decayed and now
+func (s *shard[K]) decay(halfLife time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.decayLocked(halfLife, now)
+}
+
+// decayLocked is decay for callers already holding s.mu
+func (s *shard[K]) decayLocked(halfLife time.Duration, now time.Time) {
+	elapsed := now.Sub(s.decayedAt)
+	if elapsed <= 0 {
+		return
+	}
+	s.decayedAt = now
+	s.scaleLocked(math.Exp2(-float64(elapsed) / float64(halfLife)))
+}
+
+// catchUpLocked applies the decay a lazily decayed shard owes, once at
+// least a step's worth of time has passed. The caller must hold s.mu.
+func (s *shard[K]) catchUpLocked() {
+	now := s.now()
+	if now.Sub(s.decayedAt) >= s.lazyHalfLife/decayStepsPerHalfLife {
+		s.decayLocked(s.lazyHalfLife, now)
+	}
+}
+
+// scaleLocked multiplies every weight in the shard by factor. Frequencies are
+// the weights rounded to the nearest integer. Rounding can make keys whose
+// frequencies tied, and were ranked by key, differ again after scaling, or
+// differ the other way, so the top N is selected afresh afterwards.
+func (s *shard[K]) scaleLocked(factor float64) {
+	for key, weight := range s.weights {
+		weight *= factor
+		s.weights[key] = weight
+		kf := s.keyFreqs[key]
+		kf.Frequency = int(math.Round(weight))
+		if kf.Frequency <= 0 {
+			s.removeLocked(key)
+		}
+	}
+	s.rebuild()
+}
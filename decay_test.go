@@ -0,0 +1,114 @@
+package htracker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecay(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(3, 2)
+	ht.WithClock(clock).WithDecay(time.Minute)
+	defer ht.Close()
+
+	ht.RecordRequestN("a", 1000)
+	ht.RecordRequestN("b", 400)
+	ht.RecordRequestN("c", 3)
+
+	// Several ticks per half-life, each decaying a little
+	tick := clock.ticker(ht, time.Minute/decayStepsPerHalfLife)
+	for halfLives := 1; halfLives <= 3; halfLives++ {
+		for i := 0; i < decayStepsPerHalfLife; i++ {
+			tick()
+		}
+
+		expected := 1000 >> halfLives
+		if freq, _ := ht.GetFrequency("a"); freq < expected-1 || freq > expected+1 {
+			t.Errorf("expected 'a' to decay to about %d after %d half-lives, got %d", expected, halfLives, freq)
+		}
+	}
+
+	// Decay never reorders keys
+	hotspots := ht.GetHotspots()
+	if len(hotspots) != 2 || hotspots[0] != "a" || hotspots[1] != "b" {
+		t.Errorf("expected ['a' 'b'] in descending order, got %v", hotspots)
+	}
+
+	// "c" decayed to nothing and was dropped
+	if _, ok := ht.GetFrequency("c"); ok {
+		t.Error("expected 'c' to be dropped once it decayed to zero")
+	}
+
+	// New requests count in full
+	ht.RecordRequestN("c", 200)
+	if freq, _ := ht.GetFrequency("c"); freq != 200 {
+		t.Errorf("expected 'c' to have frequency 200, got %d", freq)
+	}
+}
+
+func TestLazyDecay(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(3, 2).WithClock(clock).WithLazyDecay(time.Minute)
+	defer ht.Close()
+	if ht.stop != nil {
+		t.Fatal("expected lazy decay not to start a ticker")
+	}
+
+	ht.RecordRequestN("a", 1000)
+	ht.RecordRequestN("b", 400)
+
+	// Less than a step decays nothing
+	clock.Advance(time.Second)
+	if freq, _ := ht.GetFrequency("a"); freq != 1000 {
+		t.Errorf("expected 'a' to stay at 1000 within a step, got %d", freq)
+	}
+
+	// Reading catches up on all the decay owed at once, and reading again
+	// doesn't apply it twice
+	clock.Advance(time.Minute - time.Second)
+	for i := 0; i < 2; i++ {
+		if freq, _ := ht.GetFrequency("a"); freq != 500 {
+			t.Errorf("read %d: expected 'a' to decay to 500 after one half-life, got %d", i, freq)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	counts := ht.GetHotspotsWithCounts()
+	if len(counts) != 2 || counts[0].Frequency != 250 || counts[1].Frequency != 100 {
+		t.Errorf("expected [a:250 b:100] after two half-lives, got %v", counts)
+	}
+
+	// Recording catches up first, so the new request isn't decayed
+	clock.Advance(time.Minute)
+	ht.RecordRequestN("b", 50)
+	if freq, _ := ht.GetFrequency("b"); freq != 100 {
+		t.Errorf("expected 'b' to be 50+50 after a third half-life, got %d", freq)
+	}
+}
+
+func TestDecayReranksTies(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithDecay(time.Minute)
+	defer ht.Close()
+
+	// a and b tie, so a holds the single slot as the smaller key
+	ht.RecordRequestN("a", 3)
+	ht.RecordRequestN("b", 3)
+	if got := ht.GetHotspots(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("expected [a] before decay, got %v", got)
+	}
+
+	// Unrounded weights that round to the same frequency now but apart
+	// once scaled: a becomes 1 and b 2
+	s := ht.shards[0]
+	s.mu.Lock()
+	s.weights["a"], s.weights["b"] = 2.6, 3.4
+	s.scaleLocked(0.5)
+	s.mu.Unlock()
+
+	if got := ht.GetHotspotsWithCounts(); len(got) != 1 || got[0].Key != "b" || got[0].Frequency != 2 {
+		t.Errorf("expected [b:2] after decay, got %v", got)
+	}
+	if err := ht.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,28 @@
+package htracker
+
+// DiffSince compares the current hotspots to prev, typically the result of
+// an earlier GetHotspots, and returns the keys that became hotspots since,
+// most frequent first, and the keys that no longer are, in their order in
+// prev. It keeps no state: a poller keeps the previous set itself and
+// updates it with added and removed.
+func (ht *HotspotTrackerOf[K]) DiffSince(prev []K) (added, removed []K) {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	was := make(map[K]struct{}, len(prev))
+	for _, key := range prev {
+		was[key] = struct{}{}
+	}
+	for i := len(aggregateShard.minHeap) - 1; i >= 0; i-- {
+		key := aggregateShard.minHeap[i].Key
+		if _, ok := was[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	for _, key := range prev {
+		if _, ok := aggregateShard.keyFreqs[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,38 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffSince(t *testing.T) {
+	ht := NewHotspotTracker(2, 4)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+
+	added, removed := ht.DiffSince(nil)
+	if !slices.Equal(added, []string{"a", "b"}) || removed != nil {
+		t.Errorf("from nothing: expected +[a b] -[], got +%v -%v", added, removed)
+	}
+
+	prev := ht.GetHotspots()
+	if added, removed := ht.DiffSince(prev); added != nil || removed != nil {
+		t.Errorf("unchanged: expected no difference, got +%v -%v", added, removed)
+	}
+
+	// c overtakes b
+	ht.RecordRequestN("c", 4)
+	added, removed = ht.DiffSince(prev)
+	if !slices.Equal(added, []string{"c"}) || !slices.Equal(removed, []string{"b"}) {
+		t.Errorf("c overtakes b: expected +[c] -[b], got +%v -%v", added, removed)
+	}
+
+	// d and e overtake both
+	prev = ht.GetHotspots()
+	ht.RecordRequestN("d", 9)
+	ht.RecordRequestN("e", 7)
+	added, removed = ht.DiffSince(prev)
+	if !slices.Equal(added, []string{"d", "e"}) || !slices.Equal(removed, []string{"a", "c"}) {
+		t.Errorf("d and e overtake: expected +[d e] -[a c], got +%v -%v", added, removed)
+	}
+}
@@ -0,0 +1,93 @@
+package htracker
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventReason identifies a kind of internal condition worth surfacing to operators
+type EventReason string
+
+const (
+	// ReasonCorruption is logged when a corrupted shard heap is detected and rebuilt
+	ReasonCorruption EventReason = "corruption"
+	// ReasonSampled counts the calls WithSampleRate skipped. They are
+	// counted without taking the log's lock, so the event has no Detail
+	// or LastSeen.
+	ReasonSampled EventReason = "sampled"
+	// ReasonLate is logged when RecordRequestAt drops an event further
+	// behind the watermark than WithEventTime's tolerance
+	ReasonLate EventReason = "late"
+	// ReasonTrimmed is logged when WithMaxKeysPerShard makes a shard
+	// forget its least frequent keys
+	ReasonTrimmed EventReason = "trimmed"
+	// ReasonUntracked counts the requests ignored for keys outside
+	// SetTrackedKeys' universe. Like ReasonSampled, it has no Detail or
+	// LastSeen.
+	ReasonUntracked EventReason = "untracked"
+)
+
+// Event summarises every occurrence of one internal condition.
+// Only the most recent occurrence's detail is kept.
+type Event struct {
+	Reason   EventReason
+	Count    int64
+	Detail   string
+	LastSeen time.Time
+}
+
+// eventLog keeps one Event per reason, so its size is bounded by the
+// number of reasons rather than the number of occurrences
+type eventLog struct {
+	mu     sync.Mutex
+	events map[EventReason]*Event
+
+	// sampledOut and untracked count ReasonSampled and ReasonUntracked
+	// events, which happen on the record path too often to take mu
+	sampledOut atomic.Int64
+	untracked  atomic.Int64
+}
+
+// recordEvent logs an occurrence of reason, stamped with the tracker's clock
+func (ht *HotspotTrackerOf[K]) recordEvent(reason EventReason, detail string) {
+	ht.events.record(reason, detail, ht.clock.Now())
+}
+
+func (l *eventLog) record(reason EventReason, detail string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.events == nil {
+		l.events = make(map[EventReason]*Event)
+	}
+	ev, exists := l.events[reason]
+	if !exists {
+		ev = &Event{Reason: reason}
+		l.events[reason] = ev
+	}
+	ev.Count++
+	ev.Detail = detail
+	ev.LastSeen = now
+}
+
+// Events returns a copy of the internal event log ordered by reason.
+// It gives visibility into conditions the tracker otherwise handles silently.
+func (ht *HotspotTrackerOf[K]) Events() []Event {
+	ht.events.mu.Lock()
+	defer ht.events.mu.Unlock()
+
+	events := make([]Event, 0, len(ht.events.events)+2)
+	for _, ev := range ht.events.events {
+		events = append(events, *ev)
+	}
+	if n := ht.events.sampledOut.Load(); n > 0 {
+		events = append(events, Event{Reason: ReasonSampled, Count: n})
+	}
+	if n := ht.events.untracked.Load(); n > 0 {
+		events = append(events, Event{Reason: ReasonUntracked, Count: n})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Reason < events[j].Reason })
+	return events
+}
@@ -0,0 +1,76 @@
+package htracker
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	ht := NewHotspotTracker(3, 1).WithCorruptionDetection()
+	if events := ht.Events(); len(events) != 0 {
+		t.Fatalf("expected no events, got %v", events)
+	}
+
+	for _, key := range []string{"a", "a", "b", "c"} {
+		ht.RecordRequest(key)
+	}
+
+	s := ht.shards[0]
+	s.keyFreqs["a"].Index = 9
+	ht.RecordRequest("a")
+	s.keyFreqs["b"].Index = 42
+	ht.RecordRequest("b")
+
+	events := ht.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %v", events)
+	}
+	ev := events[0]
+	if ev.Reason != ReasonCorruption {
+		t.Errorf("expected reason %q, got %q", ReasonCorruption, ev.Reason)
+	}
+	if ev.Count != 2 {
+		t.Errorf("expected count 2, got %d", ev.Count)
+	}
+	if !strings.Contains(ev.Detail, `"b"`) {
+		t.Errorf("expected detail to describe the latest corruption, got %q", ev.Detail)
+	}
+	if ev.LastSeen.IsZero() {
+		t.Error("expected LastSeen to be set")
+	}
+
+	// Returned events are copies
+	events[0].Count = 100
+	if ht.Events()[0].Count != 2 {
+		t.Error("expected Events to return a copy")
+	}
+}
+
+func TestEventsTrimmedAndUntracked(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(100, 0)}
+	ht := NewHotspotTracker(1, 1).WithClock(clock).WithMaxKeysPerShard(4)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		ht.RecordRequest(key)
+	}
+	ht.SetTrackedKeys([]string{"a"})
+	ht.RecordRequest("x")
+	ht.RecordRequest("y")
+
+	events := make(map[EventReason]Event)
+	for _, ev := range ht.Events() {
+		events[ev.Reason] = ev
+	}
+
+	// The fifth key took the shard over 4, back down to 3
+	trimmed := events[ReasonTrimmed]
+	if trimmed.Count != 1 || !strings.Contains(trimmed.Detail, "forgot 2 keys") {
+		t.Errorf("expected one trim of 2 keys, got %+v", trimmed)
+	}
+	if !trimmed.LastSeen.Equal(clock.Now()) {
+		t.Errorf("expected the event to be stamped with the tracker's clock %v, got %v", clock.Now(), trimmed.LastSeen)
+	}
+	if untracked := events[ReasonUntracked]; untracked.Count != 2 {
+		t.Errorf("expected 2 untracked requests, got %+v", untracked)
+	}
+}
@@ -0,0 +1,125 @@
+package htracker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEventTimeDisabled is returned by RecordRequestAt when WithEventTime was
+// not called
+var ErrEventTimeDisabled = errors.New("htracker: event time is not enabled")
+
+// LatePolicy is what RecordRequestAt does with an event further behind the
+// watermark than the out-of-order tolerance
+type LatePolicy int
+
+const (
+	// LateDrop ignores late events, logging them to Events as ReasonLate
+	LateDrop LatePolicy = iota
+	// LateOldest counts late events in the oldest bucket of the window
+	LateOldest
+)
+
+// WithEventTime drives the window by the timestamps passed to
+// RecordRequestAt rather than by the clock, for replaying logs whose events
+// may be slightly out of order. The watermark, the latest timestamp
+// recorded so far, stands in for the current time: buckets expire as it
+// advances and RecordRequest counts at the watermark. An event at most
+// tolerance behind the watermark is counted in the bucket its timestamp
+// falls in, or in the oldest bucket if it is older than the window. A later
+// event is dropped or counted in the oldest bucket, as late says. The
+// watermark starts at the clock's current time. It must be called after
+// WithWindow and before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithEventTime(tolerance time.Duration, late LatePolicy) *HotspotTrackerOf[K] {
+	if ht.shards[0].window == nil {
+		panic("htracker: WithEventTime requires WithWindow")
+	}
+	ht.eventTime = true
+	ht.tolerance = tolerance
+	ht.latePolicy = late
+	ht.watermark.Store(ht.clock.Now().UnixNano())
+	for _, s := range ht.shards {
+		s.window.now = ht.Watermark
+		s.window.start = ht.Watermark()
+	}
+	return ht
+}
+
+// Watermark returns the latest timestamp recorded with RecordRequestAt, or
+// the zero time without WithEventTime
+func (ht *HotspotTrackerOf[K]) Watermark() time.Time {
+	if !ht.eventTime {
+		return time.Time{}
+	}
+	return time.Unix(0, ht.watermark.Load())
+}
+
+// RecordRequestAt records a request for key that happened at t, advancing
+// the watermark if t is past it. See WithEventTime for how t is bucketed.
+func (ht *HotspotTrackerOf[K]) RecordRequestAt(key K, t time.Time) error {
+	if !ht.eventTime {
+		return ErrEventTimeDisabled
+	}
+	key = ht.normalizeKey(key)
+	for {
+		mark := ht.watermark.Load()
+		if t.UnixNano() <= mark || ht.watermark.CompareAndSwap(mark, t.UnixNano()) {
+			break
+		}
+	}
+
+	watermark := ht.Watermark()
+	late := t.Before(watermark.Add(-ht.tolerance))
+	if late && ht.latePolicy == LateDrop {
+		ht.recordEvent(ReasonLate, fmt.Sprintf("key %#v at %v, watermark %v", key, t, watermark))
+		return nil
+	}
+	n, ok := ht.sample()
+	if !ok {
+		return nil
+	}
+
+	ht.totalRequests.Add(int64(n))
+	ht.resizeMu.RLock()
+	shardIndex := ht.shardIndex(key)
+	change, err := ht.shards[shardIndex].recordAt(key, n, t, late)
+	ht.resizeMu.RUnlock()
+	if err != nil {
+		ht.reportCorruption(fmt.Errorf("shard %d: %w", shardIndex, err))
+	}
+	change.shard = shardIndex
+	ht.notifyChange(change)
+	ht.recordPrefix(key, n)
+	return nil
+}
+
+// recordAt records a request of weight n in the bucket t falls in, or in
+// the oldest bucket if oldest is set or t is older than the window. The
+// request is recorded as usual, which counts it in the current bucket, and
+// then moved back to its own bucket so that it expires with it, unless
+// WithMaxKeysPerShard trimmed the key straight away.
+func (s *shard[K]) recordAt(key K, n int, t time.Time, oldest bool) (heapChange[K], error) {
+	s.lockRecord()
+	defer s.mu.Unlock()
+
+	change, err := s.recordLocked(key, n)
+	if change.untracked {
+		return change, err
+	}
+	w := s.window
+	current := w.buckets[w.current]
+	ago := len(w.buckets) - 1
+	if !oldest && !t.Before(w.start) {
+		ago = 0
+	} else if !oldest {
+		ago = min(int((w.start.Sub(t)+w.width-1)/w.width), ago)
+	}
+	if _, kept := s.keyFreqs[key]; kept && ago > 0 {
+		if current[key] -= n; current[key] == 0 {
+			delete(current, key)
+		}
+		w.bucket(ago)[key] += n
+	}
+	return change, err
+}
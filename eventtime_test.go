@@ -0,0 +1,139 @@
+package htracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordRequestAt(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	for _, late := range []LatePolicy{LateDrop, LateOldest} {
+		clock := &fakeClock{now: start}
+		// One second buckets, and events may be up to 5s out of order
+		ht := newWindowedTracker(clock, 3, 2, time.Minute).WithEventTime(5*time.Second, late)
+
+		for _, event := range []struct {
+			key     string
+			seconds int
+		}{{"a", 10}, {"a", 8}, {"b", 9}, {"c", 2}} {
+			if err := ht.RecordRequestAt(event.key, at(event.seconds)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := ht.Watermark(); !got.Equal(at(10)) {
+			t.Errorf("expected the watermark at 10s, got %v", got)
+		}
+
+		// Mildly out of order events land in their own buckets
+		for ago, expected := range map[int][]KeyFreq{
+			0: {{Key: "a", Frequency: 1}},
+			1: {{Key: "b", Frequency: 1}},
+			2: {{Key: "a", Frequency: 1}},
+		} {
+			got, err := ht.GetHotspotsAt(ago)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertKeyFreqs(t, got, expected)
+		}
+
+		// "c" is 8s behind the watermark, more than the tolerance
+		oldest, err := ht.GetHotspotsAt(windowBuckets - 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lateEvents int64
+		for _, ev := range ht.Events() {
+			if ev.Reason == ReasonLate {
+				lateEvents = ev.Count
+			}
+		}
+		switch late {
+		case LateDrop:
+			assertKeyFreqs(t, oldest, nil)
+			if lateEvents != 1 {
+				t.Errorf("expected 1 late event to be logged, got %d", lateEvents)
+			}
+		case LateOldest:
+			assertKeyFreqs(t, oldest, []KeyFreq{{Key: "c", Frequency: 1}})
+			if lateEvents != 0 {
+				t.Errorf("expected no late events to be logged, got %d", lateEvents)
+			}
+		}
+
+		// The window follows the watermark, not the clock
+		if err := ht.RecordRequestAt("d", at(69)); err != nil {
+			t.Fatal(err)
+		}
+		assertKeyFreqs(t, ht.GetHotspotsWithCounts(), []KeyFreq{{Key: "a", Frequency: 1}, {Key: "d", Frequency: 1}})
+		if err := ht.RecordRequestAt("d", at(71)); err != nil {
+			t.Fatal(err)
+		}
+		assertKeyFreqs(t, ht.GetHotspotsWithCounts(), []KeyFreq{{Key: "d", Frequency: 2}})
+	}
+}
+
+func TestRecordRequestAtDisabled(t *testing.T) {
+	if err := NewHotspotTracker(1, 1).RecordRequestAt("a", time.Now()); !errors.Is(err, ErrEventTimeDisabled) {
+		t.Errorf("expected ErrEventTimeDisabled, got %v", err)
+	}
+	if got := NewHotspotTracker(1, 1).Watermark(); !got.IsZero() {
+		t.Errorf("expected no watermark, got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected WithEventTime without a window to panic")
+		}
+	}()
+	NewHotspotTracker(1, 1).WithEventTime(time.Second, LateDrop)
+}
+
+func TestRecordRequestAtTrimmed(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	clock := &fakeClock{now: start}
+	ht := newWindowedTracker(clock, 1, 1, time.Minute).
+		WithMaxKeysPerShard(4).
+		WithEventTime(5*time.Second, LateDrop)
+	for key, n := range map[string]int{"hot": 5, "a": 2, "b": 3, "c": 4} {
+		for i := 0; i < n; i++ {
+			if err := ht.RecordRequestAt(key, at(10)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// "d" is out of order and trimmed as soon as it is recorded
+	if err := ht.RecordRequestAt("d", at(8)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ht.GetFrequency("d"); ok {
+		t.Fatal("expected 'd' to be trimmed")
+	}
+	for i, bucket := range ht.shards[0].window.buckets {
+		for key, count := range bucket {
+			if count <= 0 {
+				t.Errorf("bucket %d: expected no non-positive counts, got %q at %d", i, key, count)
+			}
+		}
+	}
+
+	// A returning "d" starts over and expires with its bucket
+	if err := ht.RecordRequestAt("d", at(10)); err != nil {
+		t.Fatal(err)
+	}
+	if freq, _ := ht.GetFrequency("d"); freq != 1 {
+		t.Errorf("expected 'd' to start over at 1, got %d", freq)
+	}
+	if err := ht.RecordRequestAt("hot", at(80)); err != nil {
+		t.Fatal(err)
+	}
+	if freq, ok := ht.GetFrequency("d"); ok {
+		t.Errorf("expected 'd' to expire with its bucket, got %d", freq)
+	}
+}
@@ -0,0 +1,29 @@
+package htracker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ExportCSV writes the current hotspots to w as CSV, most frequent first,
+// after a key,frequency header row. Keys are written in their %v form and
+// quoted where needed. It reads the aggregate like GetHotspotsWithCounts,
+// so it respects the cache.
+func (ht *HotspotTrackerOf[K]) ExportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"key", "frequency"}); err != nil {
+		return fmt.Errorf("htracker: writing CSV header: %w", err)
+	}
+	for _, kf := range ht.GetHotspotsWithCounts() {
+		if err := cw.Write([]string{fmt.Sprint(kf.Key), strconv.Itoa(kf.Frequency)}); err != nil {
+			return fmt.Errorf("htracker: writing CSV row: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("htracker: writing CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package htracker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExportCSV(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	ht.RecordRequestN("plain", 5)
+	ht.RecordRequestN("a,b", 9)
+	ht.RecordRequestN(`say "hi"`, 7)
+	ht.RecordRequest("cold")
+
+	var buf bytes.Buffer
+	if err := ht.ExportCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected valid CSV, got %v", err)
+	}
+
+	expected := [][]string{
+		{"key", "frequency"},
+		{"a,b", "9"},
+		{`say "hi"`, "7"},
+		{"plain", "5"},
+	}
+	if !slices.EqualFunc(records, expected, slices.Equal[[]string]) {
+		t.Errorf("expected %q, got %q", expected, records)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestExportCSVWriteError(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	ht.RecordRequest("a")
+	if err := ht.ExportCSV(failingWriter{}); err == nil {
+		t.Error("expected the writer's error")
+	}
+}
@@ -0,0 +1,68 @@
+package htracker
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// The record fast path increments KeyFreq.Frequency atomically while only
+// holding the shard's read lock. Every access to a live shard's frequencies
+// made under the read lock therefore goes through loadFrequency; accesses
+// under the write lock are exclusive and may stay plain.
+
+// loadFrequency atomically reads *p
+func loadFrequency(p *int) int {
+	if unsafe.Sizeof(*p) == 8 {
+		return int(atomic.LoadInt64((*int64)(unsafe.Pointer(p))))
+	}
+	return int(atomic.LoadInt32((*int32)(unsafe.Pointer(p))))
+}
+
+// casFrequency atomically replaces *p with new if it still holds old
+func casFrequency(p *int, old, new int) bool {
+	if unsafe.Sizeof(*p) == 8 {
+		return atomic.CompareAndSwapInt64((*int64)(unsafe.Pointer(p)), int64(old), int64(new))
+	}
+	return atomic.CompareAndSwapInt32((*int32)(unsafe.Pointer(p)), int32(old), int32(new))
+}
+
+// tryIncrement adds n to key's frequency under the read lock, reporting
+// false when the write lock is needed instead. That is the case for keys
+// outside the heap, which may need admitting, and for modes that keep
+// bookkeeping besides the frequency.
+//
+// An increment can only break the min-heap ordering by lifting a key above
+// one of its children, so it is applied only while the key still ranks
+// below both. Every concurrent change under the read lock is an increment
+// too, so children can only grow between the check and the swap, and a
+// successful swap leaves the heap ordered.
+func (s *shard[K]) tryIncrement(key K, n int) bool {
+	if s.window != nil || s.weights != nil || s.timestamps || s.observations != nil || s.sketch != nil || s.floatWeights || s.checkInvariants {
+		return false
+	}
+
+	s.rlockRecord()
+	defer s.mu.RUnlock()
+
+	kf, exists := s.keyFreqs[key]
+	if !exists || kf.Index < 0 {
+		return false
+	}
+	for {
+		old := loadFrequency(&kf.Frequency)
+		freq := old + n
+		for _, child := range []int{2*kf.Index + 1, 2*kf.Index + 2} {
+			if child >= len(s.minHeap) {
+				continue
+			}
+			c := s.minHeap[child]
+			if cf := loadFrequency(&c.Frequency); freq > cf || freq == cf && compareKeys(key, c.Key) < 0 {
+				return false
+			}
+		}
+		if casFrequency(&kf.Frequency, old, freq) {
+			s.observeFrequency(freq)
+			return true
+		}
+	}
+}
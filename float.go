@@ -0,0 +1,94 @@
+package htracker
+
+import (
+	"fmt"
+	"math"
+)
+
+// WithFloatWeights lets keys accumulate fractional weights recorded with
+// RecordRequestFloat. Each key's total is kept unrounded in KeyFreq.Weight,
+// which orders the hotspots, while Frequency holds it rounded to the
+// nearest integer for the int API. RecordRequest and RecordRequestN add
+// whole weights. It must be called before any request is recorded, and
+// can't be combined with WithWindow, WithDecay, WithLazyDecay or
+// WithSketch: combining them panics, whichever is called first.
+func (ht *HotspotTrackerOf[K]) WithFloatWeights() *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.floatWeights = true
+	}
+	ht.checkFloatWeights()
+	return ht
+}
+
+// checkFloatWeights panics if float weights were combined with a mode that
+// keeps integer per-key state of its own
+func (ht *HotspotTrackerOf[K]) checkFloatWeights() {
+	s := ht.shards[0]
+	if !s.floatWeights {
+		return
+	}
+	switch {
+	case s.window != nil:
+		panic("htracker: WithFloatWeights cannot be combined with WithWindow")
+	case s.weights != nil:
+		panic("htracker: WithFloatWeights cannot be combined with WithDecay or WithLazyDecay")
+	case s.sketch != nil:
+		panic("htracker: WithFloatWeights cannot be combined with WithSketch")
+	}
+}
+
+// RecordRequestFloat records a request of weight w, which may be
+// fractional. Without WithFloatWeights w is rounded to the nearest integer.
+// Non-positive weights are ignored. Like RecordRequestN, the request is
+// sampled, and adds its weight, rounded, to TotalRequests and to its
+// prefix under WithPrefixRollup.
+func (ht *HotspotTrackerOf[K]) RecordRequestFloat(key K, w float64) {
+	weight, ok := ht.sample()
+	if !(w > 0) || !ok {
+		return
+	}
+	w *= float64(weight)
+	n := int(math.Round(w))
+	key = ht.normalizeKey(key)
+	ht.totalRequests.Add(int64(n))
+	ht.resizeMu.RLock()
+	shardIndex := ht.shardIndex(key)
+	change, err := ht.shards[shardIndex].recordWeight(key, n, w)
+	ht.resizeMu.RUnlock()
+	if err != nil {
+		ht.reportCorruption(fmt.Errorf("shard %d: %w", shardIndex, err))
+	}
+	change.shard = shardIndex
+	ht.notifyChange(change)
+	ht.recordPrefix(key, n)
+}
+
+// GetWeight returns the unrounded weight of key under WithFloatWeights,
+// and whether it is tracked. Without WithFloatWeights it returns the
+// frequency.
+func (ht *HotspotTrackerOf[K]) GetWeight(key K) (float64, bool) {
+	key = ht.normalizeKey(key)
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+
+	s := ht.shards[ht.shardIndex(key)]
+	s.expire()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		return 0, false
+	}
+	if s.floatWeights {
+		return kf.Weight, true
+	}
+	return float64(loadFrequency(&kf.Frequency)), true
+}
+
+// recordWeight is recordWeightLocked taking the shard's lock
+func (s *shard[K]) recordWeight(key K, n int, w float64) (heapChange[K], error) {
+	s.lockRecord()
+	defer s.mu.Unlock()
+
+	return s.recordWeightLocked(key, n, w)
+}
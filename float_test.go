@@ -0,0 +1,128 @@
+package htracker
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithFloatWeights(t *testing.T) {
+	ht := NewHotspotTracker(3, 4).WithFloatWeights()
+	for _, r := range []struct {
+		key string
+		w   float64
+	}{
+		{"a", 0.5}, {"b", 1.3}, {"a", 0.75}, {"c", 0.3}, {"d", 1.4}, {"c", 0.3}, {"e", 0.1},
+	} {
+		ht.RecordRequestFloat(r.key, r.w)
+	}
+	ht.RecordRequest("c")
+
+	// a, b and d all round to 1, but their weights tell them apart
+	got := ht.GetHotspotsWithCounts()
+	expected := []KeyFreq{
+		{Key: "c", Frequency: 2, Weight: 1.6},
+		{Key: "d", Frequency: 1, Weight: 1.4},
+		{Key: "b", Frequency: 1, Weight: 1.3},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i].Key != expected[i].Key || got[i].Frequency != expected[i].Frequency ||
+			math.Abs(got[i].Weight-expected[i].Weight) > 1e-9 {
+			t.Errorf("position %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+	if w, ok := ht.GetWeight("a"); !ok || w != 1.25 {
+		t.Errorf("expected a to weigh 1.25, got %v, %v", w, ok)
+	}
+	if freq, _ := ht.GetFrequency("e"); freq != 0 {
+		t.Errorf("expected e's 0.1 to round to frequency 0, got %d", freq)
+	}
+
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewHotspotTracker(3, 4).WithFloatWeights()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.GetHotspots(); !slices.Equal(got, []string{"c", "d", "b"}) {
+		t.Errorf("expected the weights to survive a snapshot, got %v", got)
+	}
+}
+
+func TestFloatWeightAdmission(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithFloatWeights()
+	ht.RecordRequestFloat("a", 1.2)
+	ht.RecordRequestFloat("b", 1.4)
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("expected b's larger weight to evict a, got %v", got)
+	}
+}
+
+func TestRecordRequestFloatWithoutFloatWeights(t *testing.T) {
+	ht := NewHotspotTracker(2, 1)
+	ht.RecordRequestFloat("a", 1.6)
+	ht.RecordRequestFloat("a", 0)
+	if freq, _ := ht.GetFrequency("a"); freq != 2 {
+		t.Errorf("expected 1.6 to be rounded to 2, got %d", freq)
+	}
+	if w, _ := ht.GetWeight("a"); w != 2 {
+		t.Errorf("expected the weight to be the frequency, got %v", w)
+	}
+}
+
+func TestRecordRequestFloatTotals(t *testing.T) {
+	ht := NewHotspotTracker(2, 1).WithFloatWeights().WithPrefixRollup(1)
+	ht.RecordRequestFloat("/api/users", 10.5)
+	if total := ht.TotalRequests(); total != 11 {
+		t.Errorf("expected the rounded weight 11 in TotalRequests, got %d", total)
+	}
+	if share := ht.HotspotShare(); share != 1 {
+		t.Errorf("expected a share of 1, got %v", share)
+	}
+	if got := ht.GetPrefixHotspots(); !slices.Equal(got, []string{"/api"}) {
+		t.Errorf("expected the prefix to be rolled up, got %v", got)
+	}
+}
+
+func TestRecordRequestFloatSampled(t *testing.T) {
+	ht := NewHotspotTracker(2, 1).WithFloatWeights().WithSampleRate(0.25).WithRandSource(rand.NewPCG(1, 2))
+	for i := 0; i < 400; i++ {
+		ht.RecordRequestFloat("a", 1.5)
+	}
+	w, _ := ht.GetWeight("a")
+	if kept := w / 6; kept != math.Trunc(kept) || kept < 50 || kept > 150 {
+		t.Errorf("expected sampled calls to count 4 times over, got weight %v", w)
+	}
+	if freq, _ := ht.GetFrequency("a"); int64(freq) != ht.TotalRequests() {
+		t.Errorf("expected TotalRequests %d to match the frequency %d", ht.TotalRequests(), freq)
+	}
+}
+
+func TestWithFloatWeightsIncompatibleModes(t *testing.T) {
+	tests := map[string]func() *HotspotTracker{
+		"window first": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithWindow(time.Minute).WithFloatWeights() },
+		"window after": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithFloatWeights().WithWindow(time.Minute) },
+		"decay after":  func() *HotspotTracker { return NewHotspotTracker(1, 1).WithFloatWeights().WithDecay(time.Minute) },
+		"lazy decay":   func() *HotspotTracker { return NewHotspotTracker(1, 1).WithLazyDecay(time.Minute).WithFloatWeights() },
+		"sketch first": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithSketch(64, 4).WithFloatWeights() },
+		"sketch after": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithFloatWeights().WithSketch(64, 4) },
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			build().Close()
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package htracker
+
+import "sync"
+
+// floorSamples is the number of recent floor values FloorTrend compares
+const floorSamples = 8
+
+// floorHistory is a ring of the admission floor sampled on each aggregation
+type floorHistory struct {
+	mu      sync.Mutex
+	samples [floorSamples]int
+	n       int // number of samples recorded, capped at floorSamples
+	next    int // position of the next sample
+}
+
+func (f *floorHistory) record(floor int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.samples[f.next] = floor
+	f.next = (f.next + 1) % floorSamples
+	if f.n < floorSamples {
+		f.n++
+	}
+}
+
+// trend returns the newest sample minus the oldest one still held
+func (f *floorHistory) trend() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.n < 2 {
+		return 0
+	}
+	newest := f.samples[(f.next-1+floorSamples)%floorSamples]
+	oldest := f.samples[(f.next-f.n+floorSamples)%floorSamples]
+	return newest - oldest
+}
+
+// floor returns the frequency a key must reach to enter the aggregate
+// top-N, or 0 while there is still room
+func (s *shard[K]) floor() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.minHeap) < s.topN || len(s.minHeap) == 0 {
+		return 0
+	}
+	return s.minHeap[0].Frequency
+}
+
+// HotspotFloor returns the lowest frequency among the current hotspots once
+// all N slots are taken, and 0 while there is still room
+func (ht *HotspotTrackerOf[K]) HotspotFloor() int {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	return aggregateShard.floor()
+}
+
+// FloorTrend returns how much HotspotFloor has changed across the last few
+// aggregations. A rising floor means traffic is concentrating on the
+// hotspots; a falling one means it is becoming more diffuse. It returns 0
+// until at least two aggregations have run.
+func (ht *HotspotTrackerOf[K]) FloorTrend() int {
+	return ht.floors.trend()
+}
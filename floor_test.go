@@ -0,0 +1,61 @@
+package htracker
+
+import "testing"
+
+func TestHotspotFloor(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	if floor := ht.HotspotFloor(); floor != 0 {
+		t.Errorf("expected floor 0 for an empty tracker, got %d", floor)
+	}
+
+	ht.RecordRequestN("a", 5)
+	if floor := ht.HotspotFloor(); floor != 0 {
+		t.Errorf("expected floor 0 while there is room, got %d", floor)
+	}
+
+	ht.RecordRequestN("b", 3)
+	ht.RecordRequestN("c", 1)
+	if floor := ht.HotspotFloor(); floor != 3 {
+		t.Errorf("expected floor 3, got %d", floor)
+	}
+}
+
+func TestFloorTrend(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	if trend := ht.FloorTrend(); trend != 0 {
+		t.Errorf("expected no trend before any aggregation, got %d", trend)
+	}
+
+	// Diffuse traffic spread evenly over many keys
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for _, key := range keys {
+		ht.RecordRequestN(key, 2)
+	}
+	ht.GetHotspots()
+	if trend := ht.FloorTrend(); trend != 0 {
+		t.Errorf("expected no trend after a single aggregation, got %d", trend)
+	}
+
+	// Traffic increasingly concentrates on the hotspots
+	for round := 1; round <= 5; round++ {
+		for _, key := range ht.GetHotspots() {
+			ht.RecordRequestN(key, round*10)
+		}
+	}
+	ht.GetHotspots()
+	if trend := ht.FloorTrend(); trend <= 0 {
+		t.Errorf("expected a rising floor trend, got %d", trend)
+	}
+
+	// Removing the hotspots drops the floor back to the diffuse background
+	for _, key := range ht.GetHotspots() {
+		ht.RemoveKey(key)
+	}
+	for i := 0; i < floorSamples; i++ {
+		ht.RecordRequest("a")
+		ht.GetHotspots()
+	}
+	if trend := ht.FloorTrend(); trend > 0 {
+		t.Errorf("expected a flat or falling trend once concentration ends, got %d", trend)
+	}
+}
@@ -0,0 +1,60 @@
+package htracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// hotspotJSON is the wire format of a hotspot served by Handler
+type hotspotJSON[K comparable] struct {
+	Key       K   `json:"key"`
+	Frequency int `json:"frequency"`
+}
+
+// MarshalJSON encodes the current hotspots as {"hotspots": [...]}, holding
+// {"key", "frequency"} objects most frequent first like Handler. It reads
+// the aggregate like GetHotspotsWithCounts, so it respects the cache.
+func (ht *HotspotTrackerOf[K]) MarshalJSON() ([]byte, error) {
+	hotspots := ht.GetHotspotsWithCounts()
+	body := struct {
+		Hotspots []hotspotJSON[K] `json:"hotspots"`
+	}{make([]hotspotJSON[K], len(hotspots))}
+	for i, kf := range hotspots {
+		body.Hotspots[i] = hotspotJSON[K]{Key: kf.Key, Frequency: kf.Frequency}
+	}
+	return json.Marshal(body)
+}
+
+// Handler returns an http.Handler serving the current hotspots as a JSON
+// array of {"key", "frequency"} objects, most frequent first. The optional
+// n query parameter limits the response to the n most frequent hotspots.
+// Methods other than GET and HEAD are rejected with 405.
+func (ht *HotspotTrackerOf[K]) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		hotspots := ht.GetHotspotsWithCounts()
+		if s := r.URL.Query().Get("n"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, "n must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			if n < len(hotspots) {
+				hotspots = hotspots[:n]
+			}
+		}
+
+		body := make([]hotspotJSON[K], len(hotspots))
+		for i, kf := range hotspots {
+			body[i] = hotspotJSON[K]{Key: kf.Key, Frequency: kf.Frequency}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,98 @@
+package htracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+	for _, key := range []string{"a", "a", "a", "b", "b", "c", "d", "d", "d", "d"} {
+		ht.RecordRequest(key)
+	}
+	srv := httptest.NewServer(ht.Handler())
+	defer srv.Close()
+
+	get := func(query string) []hotspotJSON[string] {
+		t.Helper()
+		resp, err := http.Get(srv.URL + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		var body []hotspotJSON[string]
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	body := get("/")
+	expected := []hotspotJSON[string]{{"d", 4}, {"a", 3}, {"b", 2}}
+	if len(body) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, body)
+	}
+	for i := range expected {
+		if body[i] != expected[i] {
+			t.Errorf("expected %v at position %d, got %v", expected[i], i, body[i])
+		}
+	}
+
+	if body := get("/?n=1"); len(body) != 1 || body[0] != expected[0] {
+		t.Errorf("expected [%v] with n=1, got %v", expected[0], body)
+	}
+	if body := get("/?n=10"); len(body) != 3 {
+		t.Errorf("expected n beyond topN to return all 3 hotspots, got %v", body)
+	}
+
+	resp, err := http.Get(srv.URL + "/?n=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid n, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	ht := NewHotspotTracker(2, 2).WithCache(time.Hour)
+	defer ht.Close()
+	ht.RecordRequestN("a", 3)
+	ht.RecordRequestN("b", 5)
+	ht.RecordRequest("c")
+
+	data, err := json.Marshal(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"hotspots":[{"key":"b","frequency":5},{"key":"a","frequency":3}]}`; string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	data, err = json.Marshal(KeyFreq{Key: "a", Frequency: 3, Index: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"key":"a","frequency":3}`; string(data) != expected {
+		t.Errorf("expected KeyFreq to omit its index, got %s", data)
+	}
+}
@@ -0,0 +1,27 @@
+package htracker
+
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// FNV1a returns the 32-bit FNV-1a hash of key. It produces the same values
+// as hash/fnv's New32a but hashes the string in place without allocating.
+// It is the default hash used to pick a key's shard.
+func FNV1a(key string) uint32 {
+	hash := uint32(fnvOffset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= fnvPrime32
+	}
+	return hash
+}
+
+// WithHashFunc replaces the hash used to assign keys to shards, for example
+// with a faster non-allocating hash such as xxhash. It must be called before
+// any request is recorded, since keys already recorded would otherwise be
+// looked up in the wrong shard.
+func (ht *HotspotTrackerOf[K]) WithHashFunc(hash func(K) uint32) *HotspotTrackerOf[K] {
+	ht.hash = hash
+	return ht
+}
@@ -0,0 +1,86 @@
+package htracker
+
+import (
+	"hash/fnv"
+	"math"
+	"testing"
+)
+
+func TestFNV1aMatchesHashFNV(t *testing.T) {
+	for _, key := range []string{"", "a", "hotspot", "/users/123", "ключ"} {
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		if expected, actual := hash.Sum32(), FNV1a(key); expected != actual {
+			t.Errorf("FNV1a(%q): expected %d, got %d", key, expected, actual)
+		}
+	}
+}
+
+func TestWithHashFunc(t *testing.T) {
+	ht := NewHotspotTracker(3, 4).WithHashFunc(func(string) uint32 { return 2 })
+
+	for _, key := range []string{"a", "b", "c", "a"} {
+		ht.RecordRequest(key)
+	}
+	if n := len(ht.shards[2].keyFreqs); n != 3 {
+		t.Errorf("expected all 3 keys in shard 2, got %d", n)
+	}
+	if !ht.IsHotspot("a") {
+		t.Error("expected 'a' to be a hotspot")
+	}
+}
+
+func TestShardIndexLargeHash(t *testing.T) {
+	ht := NewHotspotTracker(3, 3).WithHashFunc(func(string) uint32 { return math.MaxUint32 })
+
+	if idx := ht.shardIndex("a"); idx != math.MaxUint32%3 {
+		t.Errorf("expected shard index %d, got %d", math.MaxUint32%3, idx)
+	}
+}
+
+// BenchmarkShardIndex compares the allocating hash/fnv hasher that used to
+// pick shards with the in-place FNV1a default.
+func BenchmarkShardIndex(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = generateKey()
+	}
+
+	b.Run("hash/fnv", func(b *testing.B) {
+		ht := NewHotspotTracker(100, 4).WithHashFunc(func(key string) uint32 {
+			hash := fnv.New32a()
+			hash.Write([]byte(key))
+			return hash.Sum32()
+		})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ht.shardIndex(keys[i%len(keys)])
+		}
+	})
+
+	b.Run("FNV1a", func(b *testing.B) {
+		ht := NewHotspotTracker(100, 4)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ht.shardIndex(keys[i%len(keys)])
+		}
+	})
+}
+
+func TestShardIndexMask(t *testing.T) {
+	ht := NewHotspotTracker(3, 8).WithHashFunc(func(key string) uint32 { return uint32(len(key)) * 37 })
+	if mask, ok := ht.sharder.(maskSharder); !ok || mask != 7 {
+		t.Fatalf("expected mask 7 for 8 shards, got %#v", ht.sharder)
+	}
+	for _, key := range []string{"", "a", "abc", "abcdefghij"} {
+		if idx, expected := ht.shardIndex(key), int(uint32(len(key))*37%8); idx != expected {
+			t.Errorf("expected %q in shard %d, got %d", key, expected, idx)
+		}
+	}
+
+	for _, numShards := range []int{1, 3, 6} {
+		if ht := NewHotspotTracker(3, numShards); ht.sharder != moduloSharder(numShards) {
+			t.Errorf("expected a modulo for %d shards, got %#v", numShards, ht.sharder)
+		}
+	}
+}
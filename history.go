@@ -0,0 +1,97 @@
+package htracker
+
+import "container/list"
+
+// WithHotspotHistory keeps exact counts for up to max former hotspots per
+// shard, for the modes that would otherwise lose or approximate them once
+// they leave the top N. WithMaxKeysPerShard never trims a remembered key,
+// and under WithSketch a current or remembered hotspot is counted exactly
+// from the moment it is admitted, on top of the sketch's estimate at that
+// point, rather than read back from the sketch. A key is remembered when it
+// is evicted from its shard's heap, and once max keys are remembered the
+// least recently evicted is forgotten and treated like any other key
+// outside the heap again. It must be called before any request is
+// recorded.
+func (ht *HotspotTrackerOf[K]) WithHotspotHistory(max int) *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.history = newDemotionHistory[K](max)
+	}
+	return ht
+}
+
+// demotionHistory is a bounded FIFO of keys evicted from a shard's heap,
+// with constant time lookup
+type demotionHistory[K comparable] struct {
+	max     int
+	order   *list.List
+	entries map[K]*list.Element
+}
+
+func newDemotionHistory[K comparable](max int) *demotionHistory[K] {
+	return &demotionHistory[K]{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// remembers reports whether key is a remembered former hotspot
+func (h *demotionHistory[K]) remembers(key K) bool {
+	_, exists := h.entries[key]
+	return exists
+}
+
+// demote remembers key as the most recently evicted hotspot, returning the
+// key it forgot to make room, if any
+func (h *demotionHistory[K]) demote(key K) (forgotten K, ok bool) {
+	if h.max <= 0 {
+		return forgotten, false
+	}
+	if el, exists := h.entries[key]; exists {
+		h.order.MoveToBack(el)
+		return forgotten, false
+	}
+	if h.order.Len() >= h.max {
+		oldest := h.order.Front()
+		forgotten, ok = h.order.Remove(oldest).(K), true
+		delete(h.entries, forgotten)
+	}
+	h.entries[key] = h.order.PushBack(key)
+	return forgotten, ok
+}
+
+// forget stops remembering key
+func (h *demotionHistory[K]) forget(key K) {
+	if el, exists := h.entries[key]; exists {
+		h.order.Remove(el)
+		delete(h.entries, key)
+	}
+}
+
+// clone returns an independent copy of the history
+func (h *demotionHistory[K]) clone() *demotionHistory[K] {
+	c := newDemotionHistory[K](h.max)
+	for el := h.order.Front(); el != nil; el = el.Next() {
+		key := el.Value.(K)
+		c.entries[key] = c.order.PushBack(key)
+	}
+	return c
+}
+
+// demoteLocked remembers kf, which was just evicted from the heap, if the
+// shard keeps a history. Under WithSketch the key it forgot to make room
+// falls back to the sketch, unless it has since been readmitted. The caller
+// must hold s.mu.
+func (s *shard[K]) demoteLocked(kf *KeyFreqOf[K]) {
+	if s.history == nil {
+		return
+	}
+	forgotten, ok := s.history.demote(kf.Key)
+	if !ok || s.sketch == nil {
+		return
+	}
+	if fkf := s.keyFreqs[forgotten]; fkf != nil && fkf.Index < 0 {
+		delete(s.keyFreqs, forgotten)
+		s.keyCount.Store(int64(len(s.keyFreqs)))
+	}
+}
@@ -0,0 +1,121 @@
+package htracker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func assertFrequency(t *testing.T, ht *HotspotTracker, key string, expected int) {
+	t.Helper()
+	if freq, ok := ht.GetFrequency(key); !ok || freq != expected {
+		t.Errorf("expected %q to have frequency %d, got %d (%v)", key, expected, freq, ok)
+	}
+}
+
+func TestHotspotHistoryTrimmed(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithMaxKeysPerShard(4).WithHotspotHistory(2)
+	cold := 0
+	recordCold := func() {
+		// Each cold key outranks "hot" once it has left the heap, so
+		// trimming would pick "hot" first if it weren't remembered
+		for i := 0; i < 10; i++ {
+			ht.RecordRequestN(fmt.Sprintf("cold%d", cold), 5)
+			cold++
+		}
+	}
+
+	ht.RecordRequestN("hot", 3)
+	ht.RecordRequestN("x", 100)
+	recordCold()
+	assertFrequency(t, ht, "hot", 3)
+
+	// Readmission resumes from the exact count, and demotes "x" in turn
+	ht.RecordRequestN("hot", 200)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "hot" {
+		t.Fatalf("expected 'hot' to be readmitted, got %v", hotspots)
+	}
+	recordCold()
+	assertFrequency(t, ht, "hot", 203)
+	assertFrequency(t, ht, "x", 100)
+
+	// A second cycle through cold and hot stays exact
+	ht.RecordRequestN("x", 200)
+	recordCold()
+	ht.RecordRequest("hot")
+	assertFrequency(t, ht, "hot", 204)
+	assertFrequency(t, ht, "x", 300)
+
+	// Without a history, "hot" is the first key trimmed
+	ht = NewHotspotTracker(1, 1).WithMaxKeysPerShard(4)
+	ht.RecordRequestN("hot", 3)
+	ht.RecordRequestN("x", 100)
+	recordCold()
+	if _, ok := ht.GetFrequency("hot"); ok {
+		t.Error("expected 'hot' to be trimmed without a history")
+	}
+}
+
+func TestHotspotHistorySketch(t *testing.T) {
+	// Every key shares the sketch's counters, so every estimate is the
+	// shard's total
+	ht := NewHotspotTracker(1, 1).
+		WithHashFunc(func(string) uint32 { return 1 }).
+		WithSketch(8, 2).
+		WithHotspotHistory(4)
+
+	ht.RecordRequestN("hot", 3)
+	ht.RecordRequestN("x", 10)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "x" {
+		t.Fatalf("expected 'x' to evict 'hot', got %v", hotspots)
+	}
+	assertFrequency(t, ht, "hot", 3)
+
+	// Readmission resumes from the exact count, and demotes "x" in turn
+	ht.RecordRequestN("hot", 20)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "hot" {
+		t.Fatalf("expected 'hot' to be readmitted, got %v", hotspots)
+	}
+	// "x" was admitted at the estimate 4, and counted exactly since
+	assertFrequency(t, ht, "hot", 23)
+	assertFrequency(t, ht, "x", 13)
+
+	// A second cycle through cold and hot stays exact
+	ht.RecordRequestN("x", 20)
+	ht.RecordRequest("hot")
+	assertFrequency(t, ht, "hot", 24)
+	assertFrequency(t, ht, "x", 33)
+
+	// Without a history, the former hotspot is left to the sketch
+	ht = NewHotspotTracker(1, 1).WithHashFunc(func(string) uint32 { return 1 }).WithSketch(8, 2)
+	ht.RecordRequestN("hot", 3)
+	ht.RecordRequestN("x", 10)
+	assertFrequency(t, ht, "hot", 13)
+}
+
+func TestHotspotHistoryBounded(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).
+		WithHashFunc(func(string) uint32 { return 1 }).
+		WithSketch(8, 2).
+		WithHotspotHistory(2)
+	s := ht.shards[0]
+
+	// Each key evicts the previous one
+	for _, key := range []string{"a", "b", "c", "d"} {
+		ht.RecordRequest(key)
+	}
+
+	if n := s.history.order.Len(); n != 2 {
+		t.Fatalf("expected the history bounded to 2 keys, got %d", n)
+	}
+	if _, exists := s.keyFreqs["a"]; exists {
+		t.Error("expected the oldest demotion 'a' to fall back to the sketch")
+	}
+	assertFrequency(t, ht, "a", 4)
+	assertFrequency(t, ht, "b", 2)
+
+	// Removing a remembered key forgets it
+	ht.RemoveKey("b")
+	if s.history.remembers("b") {
+		t.Error("expected 'b' to be forgotten once removed")
+	}
+}
@@ -0,0 +1,67 @@
+package htracker
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// heapChange describes how recording a request changed a shard's heap,
+// and what else the tracker reports once the shard lock is released.
+// Entries are copies taken under the shard lock.
+type heapChange[K comparable] struct {
+	shard    int
+	admitted *KeyFreqOf[K]
+	evicted  *KeyFreqOf[K]
+
+	// trimmed is how many keys WithMaxKeysPerShard made the shard forget
+	trimmed int
+	// untracked is set when the key was outside SetTrackedKeys' universe
+	untracked bool
+}
+
+// empty reports whether there is nothing to notify
+func (c heapChange[K]) empty() bool {
+	return c.admitted == nil && c.trimmed == 0 && !c.untracked
+}
+
+// OnHotspot registers fn to be called whenever a recorded request brings a
+// key into its shard's top N, with the key's frequency at that moment. A
+// shard's top N is a candidate set: a key entering it is not necessarily
+// among the global hotspots returned by GetHotspots. fn runs after the shard
+// lock is released, so it may call back into the tracker.
+func (ht *HotspotTrackerOf[K]) OnHotspot(fn func(key K, freq int)) *HotspotTrackerOf[K] {
+	ht.onHotspot = fn
+	return ht
+}
+
+// OnEvict registers fn to be called whenever a recorded request pushes a key
+// out of its shard's top N, with the evicted key's frequency. Like OnHotspot
+// it runs outside the shard lock.
+func (ht *HotspotTrackerOf[K]) OnEvict(fn func(key K, freq int)) *HotspotTrackerOf[K] {
+	ht.onEvict = fn
+	return ht
+}
+
+func (ht *HotspotTrackerOf[K]) notifyChange(change heapChange[K]) {
+	if change.untracked {
+		ht.events.untracked.Add(1)
+	}
+	if change.trimmed > 0 {
+		ht.recordEvent(ReasonTrimmed, fmt.Sprintf("shard %d forgot %d keys", change.shard, change.trimmed))
+	}
+	if change.evicted != nil {
+		ht.evictions.Add(1)
+		if ht.debugEnabled() {
+			ht.logDebug("htracker: hotspot evicted",
+				slog.Int("shard", change.shard),
+				slog.Any("key", change.evicted.Key),
+				slog.Int("frequency", change.evicted.Frequency))
+		}
+		if ht.onEvict != nil {
+			ht.onEvict(change.evicted.Key, change.evicted.Frequency)
+		}
+	}
+	if change.admitted != nil && ht.onHotspot != nil {
+		ht.onHotspot(change.admitted.Key, change.admitted.Frequency)
+	}
+}
@@ -0,0 +1,37 @@
+package htracker
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestOnHotspotOnEvict(t *testing.T) {
+	var admitted, evicted []string
+	ht := NewHotspotTracker(2, 1)
+	ht.OnHotspot(func(key string, freq int) {
+		// Calling back into the tracker must not deadlock
+		if got, _ := ht.GetFrequency(key); got != freq {
+			t.Errorf("expected %q to have frequency %d in the callback, got %d", key, freq, got)
+		}
+		admitted = append(admitted, fmt.Sprintf("%s:%d", key, freq))
+	}).OnEvict(func(key string, freq int) {
+		evicted = append(evicted, fmt.Sprintf("%s:%d", key, freq))
+	})
+
+	for _, key := range []string{"a", "a", "b", "a", "c", "c", "b", "d"} {
+		ht.RecordRequest(key)
+	}
+
+	// Increments of a key already in the heap don't fire again. "c" only
+	// displaces "b" once it is more frequent, "b" wins the tie at 2 by key,
+	// and "d" is never admitted.
+	expectedAdmitted := []string{"a:1", "b:1", "c:2", "b:2"}
+	if !slices.Equal(admitted, expectedAdmitted) {
+		t.Errorf("expected admissions %v, got %v", expectedAdmitted, admitted)
+	}
+	expectedEvicted := []string{"b:1", "c:2"}
+	if !slices.Equal(evicted, expectedEvicted) {
+		t.Errorf("expected evictions %v, got %v", expectedEvicted, evicted)
+	}
+}
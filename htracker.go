@@ -1,241 +1,1374 @@
 package htracker
 
 import (
+	"cmp"
 	"container/heap"
-	"hash/fnv"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// KeyFreq holds the key and its frequency
-type KeyFreq struct {
-	Key       string
-	Frequency int
-	Index     int // Index in the heap
+// KeyFreqOf holds a key of type K and its frequency
+type KeyFreqOf[K comparable] struct {
+	Key       K   `json:"key"`
+	Frequency int `json:"frequency"`
+	Index     int `json:"-"` // Index in the heap
+
+	// Weight is the unrounded frequency under WithFloatWeights, and 0
+	// otherwise
+	Weight float64 `json:"weight,omitempty"`
+
+	// FirstSeen and LastSeen are when the key was first and last recorded
+	// under WithKeyTimestamps or WithKeyTTL. They are zero otherwise, and
+	// in the copies returned by aggregate reads.
+	FirstSeen time.Time `json:"-"`
+	LastSeen  time.Time `json:"-"`
+}
+
+// String formats kf as "key=<key> freq=<frequency>"
+func (kf KeyFreqOf[K]) String() string {
+	return fmt.Sprintf("key=%v freq=%d", kf.Key, kf.Frequency)
 }
 
+// KeyFreq holds a string key and its frequency
+type KeyFreq = KeyFreqOf[string]
+
+// MinHeapOf is a min-heap of KeyFreqOf
+type MinHeapOf[K comparable] []*KeyFreqOf[K]
+
 // MinHeap is a min-heap of KeyFreq
-type MinHeap []*KeyFreq
+type MinHeap = MinHeapOf[string]
 
-func (h MinHeap) Len() int { return len(h) }
-func (h MinHeap) Less(i, j int) bool {
-	if i >= len(h) || j >= len(h) {
-		return false
+// ranksBelow reports whether a ranks below b: it is less frequent, or as
+// frequent with a greater key. Breaking ties by key makes the heap's order,
+// and so every result built from it, the same from run to run. Weights,
+// which are only set under WithFloatWeights, take precedence over the
+// rounded frequencies.
+func ranksBelow[K comparable](a, b *KeyFreqOf[K]) bool {
+	if a.Weight != b.Weight {
+		return a.Weight < b.Weight
 	}
-	return h[i].Frequency < h[j].Frequency
-}
-func (h MinHeap) Swap(i, j int) {
-	if i >= len(h) || j >= len(h) {
-		return
+	if a.Frequency != b.Frequency {
+		return a.Frequency < b.Frequency
 	}
+	return compareKeys(a.Key, b.Key) > 0
+}
+
+func (h MinHeapOf[K]) Len() int           { return len(h) }
+func (h MinHeapOf[K]) Less(i, j int) bool { return ranksBelow(h[i], h[j]) }
+func (h MinHeapOf[K]) Swap(i, j int) {
 	h[i], h[j] = h[j], h[i]
 	h[i].Index = i
 	h[j].Index = j
 }
-func (h *MinHeap) Push(x interface{}) {
+func (h *MinHeapOf[K]) Push(x interface{}) {
 	n := len(*h)
-	item := x.(*KeyFreq)
+	item := x.(*KeyFreqOf[K])
 	item.Index = n
 	*h = append(*h, item)
 }
-func (h *MinHeap) Pop() interface{} {
+
+// Pop removes and returns the last element. It returns nil for an empty
+// heap rather than panicking.
+func (h *MinHeapOf[K]) Pop() interface{} {
 	old := *h
 	n := len(old)
+	if n == 0 {
+		return nil
+	}
 	item := old[n-1]
 	item.Index = -1
 	*h = old[0 : n-1]
 	return item
 }
 
-// HotspotTracker tracks the top N keys by frequency across multiple shards
-type HotspotTracker struct {
-	shards    []*shard
+// HotspotTrackerOf tracks the top N keys of type K by frequency across
+// multiple shards
+type HotspotTrackerOf[K comparable] struct {
+	shards    []*shard[K]
 	numShards int
-	mu        sync.RWMutex
+	sharder   Sharder
+	hash      func(K) uint32
+
+	// newSharder rebuilds the sharder when Resize changes numShards
+	newSharder func(numShards int) Sharder
+
+	// shardSalt is mixed into key hashes before sharding once
+	// WithAutoRebalance has rebalanced the shards, rebalances counts the
+	// rebalances and lastRebalance is when the latest one happened, in Unix
+	// nanoseconds
+	shardSalt     uint32
+	rebalances    atomic.Int64
+	lastRebalance atomic.Int64
+
+	resizeMu  sync.RWMutex // held for reading while shards are in use
+	cooccurMu sync.RWMutex // held by RecordCooccurrence, read by aggregations
 	topN      int
-	cache     *shard
-	update    bool
-	stop      chan struct{}
+	cache     atomic.Pointer[shard[K]] // replaced wholesale on rebuild
+	update    atomic.Bool
 	withCache bool
+
+	// cacheBuiltAt is when the cached aggregate was started, in Unix
+	// nanoseconds, and maxStaleness how old it may get before a read
+	// rebuilds it regardless of the ticker
+	cacheBuiltAt atomic.Int64
+	maxStaleness time.Duration
+
+	// cacheJitter is the fraction of the cache interval by which each
+	// refresh may come early or late
+	cacheJitter float64
+
+	// minFrequency is the lowest aggregated frequency of a hotspot
+	minFrequency int
+
+	// sampleEvery is 1/rate under WithSampleRate: one call in sampleEvery
+	// is recorded, with sampleEvery times its weight
+	sampleEvery int
+
+	// rand is the source set by WithRandSource, or nil for the global one
+	rand *lockedRand
+
+	// prefixes counts key prefixes when rolled up with WithPrefixRollup
+	prefixes    *HotspotTrackerOf[K]
+	prefixDepth int
+
+	// pairs counts the pairs of keys recorded together under
+	// WithPairTracking
+	pairs *pairCounts[K]
+
+	// adaptiveMultiplier, when positive, is how many times the mean
+	// aggregated frequency a hotspot must exceed
+	adaptiveMultiplier float64
+
+	// fullAggregation makes aggregation consider every key, not just the
+	// keys in each shard's heap
+	fullAggregation bool
+
+	// tasks run from a single ticker goroutine, ticking every tick until
+	// stop is closed
+	tasks []periodicTask
+	tick  time.Duration
+	stop  chan struct{}
+
+	// aggregatePool recycles aggregates when there is no cache
+	aggregatePool sync.Pool
+
+	closeOnce sync.Once
+	workers   sync.WaitGroup // background goroutines
+
+	corruptionEvents atomic.Int64
+	onCorruption     func(error)
+
+	keyTemplates  []keyTemplate
+	keyNormalizer func(K) K
+
+	totalRequests atomic.Int64
+
+	events eventLog
+
+	aggregationObserver func(AggregateStats)
+	onHotspot           func(key K, freq int)
+	onEvict             func(key K, freq int)
+	aggregations        atomic.Int64
+	cacheRebuilds       atomic.Int64
+	evictions           atomic.Int64
+	floors              floorHistory
+	subscribers         subscribers[K]
+
+	// eventTime is set by WithEventTime, which drives the window by the
+	// watermark, the latest time passed to RecordRequestAt in Unix
+	// nanoseconds, and tolerates events up to tolerance behind it
+	eventTime  bool
+	tolerance  time.Duration
+	latePolicy LatePolicy
+	watermark  atomic.Int64
+
+	leases   *leaseWheel[K]
+	halfLife time.Duration
+	keyTTL   time.Duration
+
+	clock  Clock
+	logger *slog.Logger
 }
 
-// NewHotspotTracker initializes a new HotspotTracker with multiple shards
+// HotspotTracker tracks the top N string keys by frequency across multiple
+// shards
+type HotspotTracker = HotspotTrackerOf[string]
+
+// NewHotspotTracker initializes a new HotspotTracker with multiple shards.
+// A power-of-two shard count is cheapest, since keys are then assigned to
+// shards with a mask instead of a division.
 func NewHotspotTracker(topN, numShards int) *HotspotTracker {
-	shards := make([]*shard, numShards)
+	return New(TopN(topN), NumShards(numShards))
+}
+
+// NewDefault initializes a new HotspotTracker with one shard per available
+// CPU, as reported by runtime.GOMAXPROCS, rounded up to a power of two
+func NewDefault(topN int) *HotspotTracker {
+	return New(TopN(topN))
+}
+
+// NewHotspotTrackerOf initializes a new tracker for keys of type K, using
+// hash to assign keys to shards. It panics if topN or numShards is not
+// positive or hash is nil.
+func NewHotspotTrackerOf[K comparable](topN, numShards int, hash func(K) uint32) *HotspotTrackerOf[K] {
+	if topN <= 0 {
+		panic(fmt.Sprintf("htracker: topN must be positive, got %d", topN))
+	}
+	if numShards <= 0 {
+		panic(fmt.Sprintf("htracker: numShards must be positive, got %d", numShards))
+	}
+	if hash == nil {
+		panic("htracker: hash must not be nil")
+	}
+
+	shards := make([]*shard[K], numShards)
 	for i := 0; i < numShards; i++ {
-		shards[i] = NewShard(topN)
+		shards[i] = newShard[K](topN)
 	}
 
-	return &HotspotTracker{
-		shards:    shards,
-		numShards: numShards,
-		topN:      topN,
+	ht := &HotspotTrackerOf[K]{
+		shards:     shards,
+		numShards:  numShards,
+		sharder:    NewModuloSharder(numShards),
+		newSharder: NewModuloSharder,
+		hash:       hash,
+		topN:       topN,
+		clock:      realClock{},
+		logger:     discardLogger,
 	}
+	return ht
 }
 
-func (ht *HotspotTracker) WithCache(interval time.Duration) *HotspotTracker {
-	ht.cache = NewShard(ht.topN)
-	ht.update = true
-	ht.stop = make(chan struct{})
+// WithFullAggregation makes every aggregation rank all keys of every shard
+// rather than only each shard's top N. Since every key lives in one shard,
+// the shards' top N contain the global top N as long as each heap is in
+// step with its counts. With this option GetHotspots and the other aggregate
+// reads rank the counts themselves and do not depend on the heaps at all.
+// The aggregation then costs O(k log k) for the k keys of each shard
+// instead of O(N log N), and no shard can be skipped.
+func (ht *HotspotTrackerOf[K]) WithFullAggregation() *HotspotTrackerOf[K] {
+	ht.fullAggregation = true
+	return ht
+}
+
+// WithMinFrequency only counts a key as a hotspot once its aggregated
+// frequency is at least min. Keys below it are still tracked and can still
+// fill the top N, but are left out of GetHotspots, IsHotspot and the other
+// aggregate reads, which may then return fewer than topN keys.
+func (ht *HotspotTrackerOf[K]) WithMinFrequency(min int) *HotspotTrackerOf[K] {
+	ht.minFrequency = min
+	return ht
+}
+
+// WithAdaptiveThreshold only counts a key as a hotspot if its aggregated
+// frequency exceeds multiplier times the mean frequency of the aggregated
+// top N, so the bar rises and falls with traffic instead of being fixed as
+// with WithMinFrequency. Keys below it are still tracked, but are left out
+// of GetHotspots, IsHotspot and the other aggregate reads. With a
+// multiplier of 1 or more the least frequent of the top N never qualifies.
+func (ht *HotspotTrackerOf[K]) WithAdaptiveThreshold(multiplier float64) *HotspotTrackerOf[K] {
+	ht.adaptiveMultiplier = multiplier
+	return ht
+}
+
+func (ht *HotspotTrackerOf[K]) WithCache(interval time.Duration) *HotspotTrackerOf[K] {
+	ht.cache.Store(newShard[K](ht.topN))
+	ht.update.Store(true)
 	ht.withCache = true
-	ht.startTicker(interval)
+	if ht.cacheJitter > 0 {
+		ht.startJitteredRefresh(interval)
+		return ht
+	}
+	ht.addTask(interval, func() { ht.update.Store(true) })
 	return ht
 }
 
-func (ht *HotspotTracker) startTicker(interval time.Duration) {
-	ticker := time.NewTicker(interval)
+// WithMaxStaleness bounds how stale WithCache's aggregate may be when it
+// is served: a read finding it older than d rebuilds it synchronously,
+// whether or not the cache interval has elapsed. The age is measured from
+// when the aggregation started, on the tracker's clock.
+func (ht *HotspotTrackerOf[K]) WithMaxStaleness(d time.Duration) *HotspotTrackerOf[K] {
+	ht.maxStaleness = d
+	return ht
+}
+
+// cacheTooStale reports whether the cached aggregate is older than
+// maxStaleness
+func (ht *HotspotTrackerOf[K]) cacheTooStale() bool {
+	if ht.maxStaleness <= 0 {
+		return false
+	}
+	age := ht.clock.Now().UnixNano() - ht.cacheBuiltAt.Load()
+	return time.Duration(age) > ht.maxStaleness
+}
+
+// periodicTask is work run from the tracker's ticker every interval
+type periodicTask struct {
+	interval time.Duration
+	run      func()
+}
+
+// withPeriodicTask runs fn over every shard each interval from the tracker's
+// ticker, then invalidates the cache since fn may have changed the counts.
+// Like the options using it, it must be called before any request is
+// recorded.
+func (ht *HotspotTrackerOf[K]) withPeriodicTask(interval time.Duration, fn func(*shard[K])) *HotspotTrackerOf[K] {
+	ht.addTask(interval, func() {
+		ht.resizeMu.RLock()
+		for _, s := range ht.shards {
+			fn(s)
+		}
+		ht.resizeMu.RUnlock()
+		if ht.withCache {
+			ht.update.Store(true)
+		}
+	})
+	return ht
+}
+
+// addTask registers run to be called every interval and restarts the
+// ticker so that it runs every task, ticking at least as often as the
+// shortest interval
+func (ht *HotspotTrackerOf[K]) addTask(interval time.Duration, run func()) {
+	interval = max(interval, 1)
+	ht.tasks = append(ht.tasks, periodicTask{interval: interval, run: run})
+	tick := ht.tick
+	if ht.stop == nil || interval < tick {
+		tick = interval
+	}
+	ht.startTicker(tick)
+}
+
+// startTicker replaces the ticker goroutine with one ticking every tick. Each
+// task runs on every interval/tick-th tick, so a task whose interval is not
+// a multiple of the tick runs at the nearest multiple.
+func (ht *HotspotTrackerOf[K]) startTicker(tick time.Duration) {
+	if ht.stop != nil {
+		close(ht.stop)
+	}
+	ht.tick = tick
+	ht.stop = make(chan struct{})
+
+	type schedule struct {
+		run          func()
+		every, ticks int
+	}
+	schedules := make([]schedule, len(ht.tasks))
+	for i, task := range ht.tasks {
+		every := int((task.interval + tick/2) / tick)
+		schedules[i] = schedule{run: task.run, every: max(every, 1)}
+	}
+
+	ticker := ht.clock.NewTicker(tick)
+	stop := ht.stop
+	ht.workers.Add(1)
 	go func() {
+		defer ht.workers.Done()
+		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
-				ht.update = true
-			case <-ht.stop:
+			case <-ticker.C():
+				for i := range schedules {
+					if schedules[i].ticks++; schedules[i].ticks == schedules[i].every {
+						schedules[i].ticks = 0
+						schedules[i].run()
+					}
+				}
+			case <-stop:
 				return
 			}
 		}
 	}()
 }
-func (ht *HotspotTracker) Close() {
-	if ht.withCache {
-		close(ht.stop)
+
+// Close stops the tracker's background goroutines without waiting for them
+// to exit. It is safe to call more than once.
+func (ht *HotspotTrackerOf[K]) Close() {
+	ht.closeOnce.Do(func() {
+		if ht.stop != nil {
+			close(ht.stop)
+		}
+	})
+}
+
+// CloseContext stops the tracker's background goroutines like Close and
+// waits for them to exit, returning ctx's error if it is done first.
+func (ht *HotspotTrackerOf[K]) CloseContext(ctx context.Context) error {
+	ht.Close()
+
+	done := make(chan struct{})
+	go func() {
+		ht.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
+// nextPowerOfTwo returns the smallest power of two that is at least n
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
 // shardIndex calculates the shard index for a given key using a hash function
-func (ht *HotspotTracker) shardIndex(key string) int {
-	hash := fnv.New32a()
-	hash.Write([]byte(key))
-	hashValue := hash.Sum32()
-	return int(hashValue) % ht.numShards
+func (ht *HotspotTrackerOf[K]) shardIndex(key K) int {
+	return ht.sharder.Shard(ht.hash(key))
 }
 
 // RecordRequest records a request with a given key
-func (ht *HotspotTracker) RecordRequest(key string) {
+func (ht *HotspotTrackerOf[K]) RecordRequest(key K) {
+	weight, ok := ht.sample()
+	if !ok {
+		return
+	}
+	key = ht.normalizeKey(key)
+	ht.record(key, weight)
+	ht.recordPrefix(key, weight)
+}
+
+// RecordRequestN records a request with a given key weighted by n, adding n
+// to the key's frequency instead of one. Non-positive weights are ignored.
+func (ht *HotspotTrackerOf[K]) RecordRequestN(key K, n int) {
+	weight, ok := ht.sample()
+	if n <= 0 || !ok {
+		return
+	}
+	n *= weight
+	key = ht.normalizeKey(key)
+	ht.record(key, n)
+	ht.recordPrefix(key, n)
+}
+
+// record records a request of weight n for an already normalized key
+func (ht *HotspotTrackerOf[K]) record(key K, n int) {
+	ht.totalRequests.Add(int64(n))
+	ht.resizeMu.RLock()
 	shardIndex := ht.shardIndex(key)
-	ht.shards[shardIndex].RecordRequest(key)
+	if ht.shards[shardIndex].tryIncrement(key, n) {
+		ht.resizeMu.RUnlock()
+		return
+	}
+	change, err := ht.shards[shardIndex].record(key, n)
+	ht.resizeMu.RUnlock()
+	if err != nil {
+		ht.reportCorruption(fmt.Errorf("shard %d: %w", shardIndex, err))
+	}
+	change.shard = shardIndex
+	ht.notifyChange(change)
 }
 
-// GetHotspots returns the list of current hotspots across all shards
-func (ht *HotspotTracker) GetHotspots() []string {
-	aggregateShard := ht.AggregateData()
+// GetHotspots returns the list of current hotspots across all shards, most
+// frequent first
+func (ht *HotspotTrackerOf[K]) GetHotspots() []K {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
 
 	return aggregateShard.GetHotspots()
 }
 
-func (ht *HotspotTracker) AggregateData() *shard {
-	ht.mu.RLock()
-	defer ht.mu.RUnlock()
+// GetHotspotsContext is GetHotspots for aggregations that may be slow
+// enough to outlive a request, as with WithFullAggregation over large
+// shards. ctx is checked before each shard is merged, and ctx.Err() is
+// returned as soon as it is done. Reads served from the cache are not
+// interrupted, and an interrupted cache rebuild is left to the next read.
+func (ht *HotspotTrackerOf[K]) GetHotspotsContext(ctx context.Context) ([]K, error) {
+	aggregateShard, err := ht.aggregateData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ht.releaseAggregate(aggregateShard)
+
+	return aggregateShard.GetHotspots(), nil
+}
 
-	var tShard *shard
+// GetHotspotsInto is like GetHotspots but appends the hotspots to dst[:0],
+// growing it only if it is too small, and returns the result in the same
+// order as GetHotspots. With WithCache, a large enough dst makes the call
+// allocation-free between refreshes.
+func (ht *HotspotTrackerOf[K]) GetHotspotsInto(dst []K) []K {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	// The aggregate's heap is built in ascending rank order, so reading it
+	// backwards gives the keys most frequent first
+	dst = dst[:0]
+	for i := len(aggregateShard.minHeap) - 1; i >= 0; i-- {
+		dst = append(dst, aggregateShard.minHeap[i].Key)
+	}
+	return dst
+}
 
-	if ht.withCache {
-		ht.mu.Lock()
-		defer ht.mu.Unlock()
+// ForEach calls fn with each hotspot and its aggregated frequency, most
+// frequent first, until fn returns false. fn sees one aggregate throughout,
+// however much is recorded meanwhile, and no slice of hotspots is built.
+func (ht *HotspotTrackerOf[K]) ForEach(fn func(key K, freq int) bool) {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
 
-		if ht.update {
-			tShard = ht.aggregateShards()
-			ht.cache = tShard
-			ht.update = false
+	for i := len(aggregateShard.minHeap) - 1; i >= 0; i-- {
+		kf := aggregateShard.minHeap[i]
+		if !fn(kf.Key, kf.Frequency) {
+			return
 		}
-		return ht.cache
 	}
+}
+
+// GetHotspotsWithCounts returns the current hotspots with their aggregated
+// frequencies, most frequent first. The returned values are copies and can
+// be modified freely.
+func (ht *HotspotTrackerOf[K]) GetHotspotsWithCounts() []KeyFreqOf[K] {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	return aggregateShard.sortedKeyFreqs()
+}
+
+// GetTopK returns the keys of the k most frequent hotspots, most frequent
+// first. k is clamped to the number of hotspots, which is at most topN.
+func (ht *HotspotTrackerOf[K]) GetTopK(k int) []K {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	return aggregateShard.topK(k)
+}
+
+// GetHotspotsByShard returns each shard's own top N keys, most frequent
+// first, indexed by shard, without aggregating across shards. A shard's top
+// keys are candidates: they need not be among the global hotspots. Each
+// shard is read under its own lock.
+func (ht *HotspotTrackerOf[K]) GetHotspotsByShard() [][]K {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+
+	byShard := make([][]K, len(ht.shards))
+	for i, s := range ht.shards {
+		s.expire()
+		byShard[i] = s.lockedHotspots()
+	}
+	return byShard
+}
 
-	tShard = ht.aggregateShards()
+// aggregate returns a shard holding the top N keys across all shards.
+// With caching enabled the same shard is returned until the next tick, so
+// it must be treated as read-only.
+func (ht *HotspotTrackerOf[K]) aggregate() *shard[K] {
+	tShard, _ := ht.aggregateData(context.Background())
 	return tShard
 }
 
-func (ht *HotspotTracker) aggregateShards() *shard {
-	tShard := NewShard(ht.topN)
+// aggregateData is aggregate, giving up with ctx's error if ctx is done
+// before every shard has been merged
+func (ht *HotspotTrackerOf[K]) aggregateData(ctx context.Context) (*shard[K], error) {
+	if !ht.withCache {
+		return ht.observeAggregation(ctx)
+	}
+
+	// Only the reader that resets the flag rebuilds the cache. Readers that
+	// lose the race keep serving the previous snapshot in the meantime,
+	// unless it is too stale to serve, in which case they rebuild too.
+	if ht.update.CompareAndSwap(true, false) || ht.cacheTooStale() {
+		builtAt := ht.clock.Now().UnixNano()
+		tShard, err := ht.observeAggregation(ctx)
+		if err != nil {
+			// Leave the rebuild to the next reader
+			ht.update.Store(true)
+			return nil, err
+		}
+		rebuilds := ht.cacheRebuilds.Add(1)
+		ht.cache.Store(tShard)
+		ht.cacheBuiltAt.Store(builtAt)
+		if ht.debugEnabled() {
+			ht.logDebug("htracker: cache rebuilt",
+				slog.Int("hotspots", len(tShard.minHeap)),
+				slog.Int64("rebuilds", rebuilds))
+		}
+		return tShard, nil
+	}
+	return ht.cache.Load(), nil
+}
+
+// observeAggregation aggregates the shards and reports the rebuild
+func (ht *HotspotTrackerOf[K]) observeAggregation(ctx context.Context) (*shard[K], error) {
+	start := time.Now()
+	tShard, stats, err := ht.aggregateShards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ht.aggregations.Add(1)
+	ht.floors.record(tShard.floor())
+	ht.subscribers.publish(tShard)
+	ht.notifyAggregation(start, stats)
+	return tShard, nil
+}
 
+// aggregateShards merges every shard's top-N into a new shard. Each shard's
+// keys are merged into the running top-N in descending frequency, so the scan
+// of a shard stops as soon as its remaining keys cannot beat the aggregate
+// floor, and a shard whose hottest key cannot beat it is skipped entirely.
+// ctx is checked before each shard, and the merge abandoned with its error
+// once it is done.
+func (ht *HotspotTrackerOf[K]) aggregateShards(ctx context.Context) (*shard[K], AggregateStats, error) {
+	var stats AggregateStats
+	var err error
+
+	tShard, _ := ht.aggregatePool.Get().(*shard[K])
+	if tShard == nil {
+		tShard = &shard[K]{
+			topN:     ht.topN,
+			minHeap:  make(MinHeapOf[K], 0, ht.topN),
+			keyFreqs: make(map[K]*KeyFreqOf[K], ht.topN),
+			scratch: &aggregateScratch[K]{
+				top:    make([]KeyFreqOf[K], 0, ht.topN),
+				merged: make([]KeyFreqOf[K], 0, ht.topN),
+			},
+		}
+	}
+	top := tShard.scratch.top[:0] // descending by frequency
+	merged := tShard.scratch.merged[:0]
+	order := tShard.scratch.order[:0]
+	candidates := tShard.scratch.candidates[:0]
+
+	// cooccurMu is taken first, as by RecordCooccurrence
+	ht.cooccurMu.RLock()
+	ht.resizeMu.RLock()
 	for _, shard := range ht.shards {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		shard.expire()
+		// maxFreq only bounds the heap, so a full aggregation can't skip
+		if !ht.fullAggregation && len(top) == ht.topN && int(shard.maxFreq.Load()) < top[len(top)-1].Frequency {
+			continue
+		}
+
 		shard.mu.RLock()
-		for _, kf := range shard.minHeap {
-			processKeyFreq(tShard, kf)
+		candidates = candidates[:0]
+		if ht.fullAggregation {
+			for _, kf := range shard.keyFreqs {
+				if shard.eligible(kf.Key) {
+					candidates = append(candidates, kf)
+				}
+			}
+		} else {
+			candidates = append(candidates, shard.minHeap...)
+		}
+		stats.ShardsProcessed++
+		stats.KeysScanned += len(candidates)
+		order = order[:0]
+		for i, kf := range candidates {
+			order = append(order, rankedIndex{freq: loadFrequency(&kf.Frequency), index: i})
+		}
+		if shard.floatWeights {
+			// Weights change only under the write lock, so they can be
+			// read in place
+			slices.SortFunc(order, func(a, b rankedIndex) int {
+				kfa, kfb := candidates[a.index], candidates[b.index]
+				return compareRank(&KeyFreqOf[K]{Key: kfa.Key, Frequency: a.freq, Weight: kfa.Weight},
+					&KeyFreqOf[K]{Key: kfb.Key, Frequency: b.freq, Weight: kfb.Weight})
+			})
+		} else {
+			slices.SortFunc(order, func(a, b rankedIndex) int {
+				if c := cmp.Compare(b.freq, a.freq); c != 0 {
+					return c
+				}
+				return compareKeys(candidates[a.index].Key, candidates[b.index].Key)
+			})
+		}
+
+		merged = merged[:0]
+		i, j := 0, 0
+		for len(merged) < ht.topN && (i < len(top) || j < len(order)) {
+			if j < len(order) && (i == len(top) || rankedAbove(order[j], candidates[order[j].index], &top[i], shard.floatWeights)) {
+				kf := candidates[order[j].index]
+				merged = append(merged, KeyFreqOf[K]{Key: kf.Key, Frequency: order[j].freq, Weight: kf.Weight})
+				j++
+			} else {
+				merged = append(merged, top[i])
+				i++
+			}
 		}
 		shard.mu.RUnlock()
+
+		top, merged = merged, top
+	}
+	ht.resizeMu.RUnlock()
+	ht.cooccurMu.RUnlock()
+	if err != nil {
+		tShard.scratch.top, tShard.scratch.merged, tShard.scratch.order = top, merged, order
+		clear(candidates)
+		tShard.scratch.candidates = candidates[:0]
+		ht.aggregatePool.Put(tShard)
+		return nil, stats, err
 	}
 
-	return tShard
+	// Keys at or below the adaptive threshold stay tracked but are not
+	// hotspots. The mean is taken before any key is cut.
+	if ht.adaptiveMultiplier > 0 && len(top) > 0 {
+		var sum int
+		for _, kf := range top {
+			sum += kf.Frequency
+		}
+		threshold := float64(sum) / float64(len(top)) * ht.adaptiveMultiplier
+		n := 0
+		for n < len(top) && float64(top[n].Frequency) > threshold {
+			n++
+		}
+		top = top[:n]
+	}
+
+	// Keys below the minimum frequency stay tracked but are not hotspots
+	if ht.minFrequency > 0 {
+		n := 0
+		for n < len(top) && top[n].Frequency >= ht.minFrequency {
+			n++
+		}
+		top = top[:n]
+	}
+
+	// The aggregate holds its own copies so it never shares a KeyFreq, and
+	// therefore an Index, with a live shard. Ascending rank order is already
+	// a valid min-heap.
+	tShard.scratch.top, tShard.scratch.merged, tShard.scratch.order = top, merged, order
+	clear(candidates) // don't keep live KeyFreqs reachable from the pool
+	tShard.scratch.candidates = candidates[:0]
+	tShard.minHeap = tShard.minHeap[:0]
+	clear(tShard.keyFreqs)
+	for i := len(top) - 1; i >= 0; i-- {
+		kf := &top[i]
+		kf.Index = len(tShard.minHeap)
+		tShard.minHeap = append(tShard.minHeap, kf)
+		tShard.keyFreqs[kf.Key] = kf
+	}
+
+	stats.Size = len(tShard.minHeap)
+	return tShard, stats, nil
+}
+
+// rankedIndex is a position in a shard's heap paired with its frequency, so
+// that sorting candidates doesn't move pointers around
+type rankedIndex struct {
+	freq  int
+	index int
+}
+
+// rankedAbove reports whether the candidate r, whose KeyFreq is c, ranks
+// above kf, as in ranksBelow. Ties go to the smaller key. Weights are only
+// compared with float weights, since they are all 0 otherwise.
+func rankedAbove[K comparable](r rankedIndex, c, kf *KeyFreqOf[K], float bool) bool {
+	if float && c.Weight != kf.Weight {
+		return c.Weight > kf.Weight
+	}
+	if r.freq != kf.Frequency {
+		return r.freq > kf.Frequency
+	}
+	return compareKeys(c.Key, kf.Key) < 0
+}
+
+// aggregateScratch holds an aggregate's buffers so that a pooled aggregate
+// can be rebuilt without allocating. The aggregate's heap points into top.
+type aggregateScratch[K comparable] struct {
+	top, merged []KeyFreqOf[K]
+	order       []rankedIndex
+	candidates  []*KeyFreqOf[K]
+}
+
+// releaseAggregate returns an aggregate that was built for a single read to
+// the pool once the read is done with it. Cached aggregates are shared by
+// concurrent readers and are never pooled.
+func (ht *HotspotTrackerOf[K]) releaseAggregate(s *shard[K]) {
+	if !ht.withCache {
+		ht.aggregatePool.Put(s)
+	}
 }
 
 // IsHotspot checks if a given key is a hotspot across all shards
-func (ht *HotspotTracker) IsHotspot(key string) bool {
+func (ht *HotspotTrackerOf[K]) IsHotspot(key K) bool {
+	key = ht.normalizeKey(key)
 
-	aggregateShard := ht.AggregateData()
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
 
 	return aggregateShard.IsHotspot(key)
 }
 
-// shard represents a shard of the hotspot tracker
-type shard struct {
+// Rank returns the 1-based position of key among the hotspots, in the order
+// of GetHotspots, or false if key is not a hotspot
+func (ht *HotspotTrackerOf[K]) Rank(key K) (int, bool) {
+	key = ht.normalizeKey(key)
+
+	// The aggregate's heap is built in ascending rank order
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+	kf, ok := aggregateShard.keyFreqs[key]
+	if !ok {
+		return 0, false
+	}
+	return len(aggregateShard.minHeap) - kf.Index, true
+}
+
+// GetFrequency returns the current frequency of key and whether it has been
+// seen. Counts are kept for every key, including keys outside the top N.
+func (ht *HotspotTrackerOf[K]) GetFrequency(key K) (int, bool) {
+	key = ht.normalizeKey(key)
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	shard := ht.shards[ht.shardIndex(key)]
+	shard.expire()
+	return shard.frequency(key)
+}
+
+// RemoveKey stops tracking key immediately and forgets its count, reporting
+// whether it was tracked. If key was a hotspot, the next most frequent key
+// of its shard takes its place.
+// With caching enabled the cache is invalidated so the removal is visible to
+// the next read.
+func (ht *HotspotTrackerOf[K]) RemoveKey(key K) bool {
+	key = ht.normalizeKey(key)
+	ht.resizeMu.RLock()
+	removed := ht.shards[ht.shardIndex(key)].remove(key)
+	ht.resizeMu.RUnlock()
+	if removed && ht.withCache {
+		ht.update.Store(true)
+	}
+	return removed
+}
+
+// PruneBelow forgets every key whose frequency is below min, for example to
+// shed long-tail keys during maintenance, and returns how many were pruned.
+// With caching enabled the cache is invalidated.
+func (ht *HotspotTrackerOf[K]) PruneBelow(min int) int {
+	pruned := 0
+	ht.resizeMu.RLock()
+	for _, shard := range ht.shards {
+		pruned += shard.pruneBelow(min)
+	}
+	ht.resizeMu.RUnlock()
+	if pruned > 0 && ht.withCache {
+		ht.update.Store(true)
+	}
+	return pruned
+}
+
+// KeysAtFrequency returns, in sorted order, every tracked key across all
+// shards whose frequency is exactly freq. It is meant for debugging why a
+// key was or wasn't admitted when many keys share a count.
+func (ht *HotspotTrackerOf[K]) KeysAtFrequency(freq int) []K {
+	var keys []K
+	ht.resizeMu.RLock()
+	for _, shard := range ht.shards {
+		shard.expire()
+		shard.mu.RLock()
+		for key, kf := range shard.keyFreqs {
+			if loadFrequency(&kf.Frequency) == freq {
+				keys = append(keys, key)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	ht.resizeMu.RUnlock()
+
+	slices.SortFunc(keys, compareKeys[K])
+	return keys
+}
+
+// GetAllFrequencies returns the frequency of every tracked key across all
+// shards, not only the hotspots. The map is a copy that the caller owns.
+// It holds one entry per distinct key, so with many keys it can be large
+// and slow to build; each shard is read under its own lock in turn.
+func (ht *HotspotTrackerOf[K]) GetAllFrequencies() map[K]int {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+
+	var size int
+	for _, shard := range ht.shards {
+		size += int(shard.keyCount.Load())
+	}
+	freqs := make(map[K]int, size)
+	for _, shard := range ht.shards {
+		shard.expire()
+		shard.mu.RLock()
+		for key, kf := range shard.keyFreqs {
+			// A key lives in a single shard, but sum in case it doesn't
+			freqs[key] += loadFrequency(&kf.Frequency)
+		}
+		shard.mu.RUnlock()
+	}
+	return freqs
+}
+
+// shard represents a shard of the hotspot tracker. keyFreqs holds the count
+// of every key seen by the shard, while minHeap holds only the top N of them.
+// Keys outside the heap have an Index of -1.
+type shard[K comparable] struct {
 	topN     int
-	minHeap  MinHeap
-	keyFreqs map[string]*KeyFreq
+	minHeap  MinHeapOf[K]
+	keyFreqs map[K]*KeyFreqOf[K]
 	mu       sync.RWMutex
+
+	checkInvariants bool
+
+	// universe restricts the shard to a fixed set of keys when non-nil
+	universe map[K]struct{}
+
+	// window holds per-bucket counts when the shard counts a sliding window
+	window *window[K]
+
+	// floatWeights makes keys accumulate fractional weights in
+	// KeyFreq.Weight, with Frequency its rounded value
+	floatWeights bool
+
+	// weights holds the unrounded frequency of every key under decay
+	weights map[K]float64
+
+	// decayedAt is when decay was last applied to the weights, and
+	// lazyHalfLife the half-life when decay is applied on access instead
+	// of from the ticker
+	decayedAt    time.Time
+	lazyHalfLife time.Duration
+
+	// timestamps makes recording set KeyFreq.FirstSeen and LastSeen from
+	// now, which also drives lazy decay
+	timestamps bool
+	now        func() time.Time
+
+	// observations counts the requests of every key, whatever their
+	// weight, when a key needs minObservations of them to enter the heap
+	observations    map[K]int
+	minObservations int
+
+	// sketch counts every key in approximate mode, where keyFreqs only
+	// holds the heap's keys and the history's
+	sketch     *countMinSketch
+	sketchHash func(K) uint32
+
+	// maxKeys bounds len(keyFreqs) when positive
+	maxKeys int
+
+	// history remembers former hotspots whose counts are kept exact
+	history *demotionHistory[K]
+
+	// scratch holds an aggregate's reusable buffers
+	scratch *aggregateScratch[K]
+
+	// keyCount mirrors len(keyFreqs) for lock-free reads
+	keyCount atomic.Int64
+
+	// contended and lockWait count the record-path lock acquisitions that
+	// waited and the nanoseconds they waited, for ShardContention
+	contended, lockWait atomic.Int64
+
+	// maxFreq is an upper bound on every frequency in the heap. It is only
+	// written under mu but may be read without it.
+	maxFreq atomic.Int64
 }
 
-func NewShard(n int) *shard {
-	h := &MinHeap{}
-	heap.Init(h)
-	return &shard{
+// newShard returns an empty shard tracking the top n keys. The heap is held
+// by value and every heap operation goes through &s.minHeap, so there is
+// never a second slice header that could drift from the shard's own.
+func newShard[K comparable](n int) *shard[K] {
+	return &shard[K]{
 		topN:     n,
-		minHeap:  *h,
-		keyFreqs: make(map[string]*KeyFreq),
+		minHeap:  make(MinHeapOf[K], 0, n),
+		keyFreqs: make(map[K]*KeyFreqOf[K]),
 	}
 }
 
-// RecordRequest records a request with a given key in a shard
-func (s *shard) RecordRequest(key string) {
-	s.mu.Lock()
+// RecordRequest records a request with a given key in a shard.
+// It returns an error only when invariant checks are enabled and the
+// shard had to be rebuilt because its heap was found corrupted.
+func (s *shard[K]) RecordRequest(key K) error {
+	return s.RecordRequestN(key, 1)
+}
+
+// RecordRequestN records a request of weight n with a given key in a shard
+func (s *shard[K]) RecordRequestN(key K, n int) error {
+	_, err := s.record(key, n)
+	return err
+}
+
+// record records a request of weight n and reports how the shard's heap
+// membership changed as a result
+func (s *shard[K]) record(key K, n int) (heapChange[K], error) {
+	s.lockRecord()
 	defer s.mu.Unlock()
 
-	if kf, exists := s.keyFreqs[key]; exists {
-		kf.Frequency++
-		heap.Fix(&s.minHeap, kf.Index)
+	return s.recordLocked(key, n)
+}
+
+// recordLocked is record for a caller already holding s.mu
+func (s *shard[K]) recordLocked(key K, n int) (heapChange[K], error) {
+	return s.recordWeightLocked(key, n, float64(n))
+}
+
+// recordWeightLocked records a request of weight n, or of the fractional
+// weight w under WithFloatWeights. The caller must hold s.mu.
+func (s *shard[K]) recordWeightLocked(key K, n int, w float64) (heapChange[K], error) {
+	var change heapChange[K]
+	if s.universe != nil {
+		if _, tracked := s.universe[key]; !tracked {
+			change.untracked = true
+			return change, nil
+		}
+	}
+	if s.sketch != nil {
+		return s.recordSketch(key, n), nil
+	}
+
+	if s.window != nil {
+		s.expireLocked()
+		s.window.buckets[s.window.current][key] += n
+	}
+	if s.lazyHalfLife > 0 {
+		s.catchUpLocked()
+	}
+	if s.weights != nil {
+		s.weights[key] += float64(n)
+	}
+	if s.observations != nil {
+		s.observations[key]++
+	}
+
+	var err error
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		kf = &KeyFreqOf[K]{Key: key, Index: -1}
+		s.keyFreqs[key] = kf
+		s.keyCount.Store(int64(len(s.keyFreqs)))
+	}
+	if s.timestamps {
+		kf.LastSeen = s.now()
+		if !exists {
+			kf.FirstSeen = kf.LastSeen
+		}
+	}
+	if s.checkInvariants && kf.Index >= 0 {
+		if err = s.checkIndex(kf); err != nil {
+			s.rebuild()
+		}
+	}
+
+	if s.floatWeights {
+		kf.Weight += w
+		kf.Frequency = int(math.Round(kf.Weight))
 	} else {
-		kf = &KeyFreq{Key: key, Frequency: 1}
+		kf.Frequency += n
+	}
+	if kf.Index >= 0 {
+		heap.Fix(&s.minHeap, kf.Index)
+		s.observeFrequency(kf.Frequency)
+	} else if !s.eligible(key) {
+		// Not observed often enough yet to take a slot
+	} else if admitted, evicted := processKeyFreq(s, kf); admitted {
+		change.admitted = &KeyFreqOf[K]{Key: key, Frequency: kf.Frequency, Index: -1}
+		if evicted != nil {
+			change.evicted = &KeyFreqOf[K]{Key: evicted.Key, Frequency: evicted.Frequency, Index: -1}
+		}
+	}
+
+	if s.checkInvariants && err == nil {
+		if err = s.checkRoot(); err != nil {
+			s.rebuild()
+		}
+	}
+	if s.maxKeys > 0 && len(s.keyFreqs) > s.maxKeys {
+		change.trimmed = s.trimLocked()
+	}
+	return change, err
+}
 
-		processKeyFreq(s, kf)
+// lockedHotspots is GetHotspots for a live shard, which it read-locks
+func (s *shard[K]) lockedHotspots() []K {
+	s.mu.RLock()
+	kfs := make([]KeyFreqOf[K], len(s.minHeap))
+	for i, kf := range s.minHeap {
+		kfs[i] = KeyFreqOf[K]{Key: kf.Key, Frequency: loadFrequency(&kf.Frequency)}
 	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(kfs, func(a, b KeyFreqOf[K]) int { return compareRank(&a, &b) })
+	keys := make([]K, len(kfs))
+	for i, kf := range kfs {
+		keys[i] = kf.Key
+	}
+	return keys
 }
 
-// GetHotspots returns the list of current hotspots in a shard
-func (s *shard) GetHotspots() []string {
-	hotspots := make([]string, len(s.minHeap))
+// GetHotspots returns the list of current hotspots in a shard, most
+// frequent first
+func (s *shard[K]) GetHotspots() []K {
+	return s.topK(len(s.minHeap))
+}
 
-	// Create a copy of the min heap to maintain state of the original
-	minHeapCopy := append(MinHeap(nil), s.minHeap...)
-	heap.Init(&minHeapCopy)
+// frequency returns the count of a key seen by the shard, whether or not it
+// is currently in the shard's top-N
+func (s *shard[K]) frequency(key K) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	// Extract elements from the min heap in sorted order of frequency
-	for i := range minHeapCopy {
-		kf := heap.Pop(&minHeapCopy).(*KeyFreq)
-		hotspots[i] = kf.Key
+	if kf, exists := s.keyFreqs[key]; exists {
+		return loadFrequency(&kf.Frequency), true
+	}
+	if s.sketch != nil {
+		est := s.sketch.estimate(s.sketchHash(key))
+		return est, est > 0
 	}
+	return 0, false
+}
 
-	return hotspots
+// sortedKeyFreqs returns copies of the shard's hotspots ordered by descending
+// frequency, with ties broken by key
+func (s *shard[K]) sortedKeyFreqs() []KeyFreqOf[K] {
+	kfs := make([]KeyFreqOf[K], len(s.minHeap))
+	for i, kf := range s.minHeap {
+		kfs[i] = *kf
+	}
+	slices.SortFunc(kfs, func(a, b KeyFreqOf[K]) int { return compareRank(&a, &b) })
+	return kfs
 }
 
-// IsHotspot checks if a given key is a hotspot in a shard
-func (s *shard) IsHotspot(key string) bool {
+// topK returns the keys of the shard's k highest ranked hotspots in the
+// order of sortedKeyFreqs. Candidates are inserted into a sorted slice of at
+// most k entries, so the whole heap is never sorted when k is small.
+func (s *shard[K]) topK(k int) []K {
+	k = min(k, len(s.minHeap))
+	if k <= 0 {
+		return []K{}
+	}
+
+	best := make([]*KeyFreqOf[K], 0, k)
+	for _, kf := range s.minHeap {
+		if len(best) == k && compareRank(kf, best[k-1]) >= 0 {
+			continue
+		}
+		i, _ := slices.BinarySearchFunc(best, kf, compareRank[K])
+		if len(best) < k {
+			best = append(best, nil)
+		}
+		copy(best[i+1:], best[i:len(best)-1])
+		best[i] = kf
+	}
+
+	keys := make([]K, len(best))
+	for i, kf := range best {
+		keys[i] = kf.Key
+	}
+	return keys
+}
+
+// compareRank orders hotspots by descending frequency, with ties broken by key
+func compareRank[K comparable](a, b *KeyFreqOf[K]) int {
+	if a.Weight != b.Weight {
+		if a.Weight > b.Weight {
+			return -1
+		}
+		return 1
+	}
+	if c := cmp.Compare(b.Frequency, a.Frequency); c != 0 {
+		return c
+	}
+	return compareKeys(a.Key, b.Key)
+}
+
+// IsHotspot checks if a given key is among the shard's top N, that is in
+// its heap. Keys that are counted but outside the heap are not hotspots.
+func (s *shard[K]) IsHotspot(key K) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	kf, exists := s.keyFreqs[key]
+	return exists && kf.Index >= 0
+}
+
+// observeFrequency raises the shard's frequency upper bound if needed. It
+// may run concurrently on the record fast path, so it never lowers the bound.
+func (s *shard[K]) observeFrequency(freq int) {
+	for {
+		bound := s.maxFreq.Load()
+		if int64(freq) <= bound || s.maxFreq.CompareAndSwap(bound, int64(freq)) {
+			return
+		}
+	}
+}
+
+// remove deletes key and its count from the shard, promoting the best key
+// outside the heap if key was a hotspot
+func (s *shard[K]) remove(key K) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.keyFreqs[key]
-	return exists
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		return false
+	}
+	hot := kf.Index >= 0
+	s.removeLocked(key)
+	if hot {
+		s.promoteLocked()
+	}
+	return true
 }
 
-// helper functions
+// removeLocked deletes key from both keyFreqs and the heap. A removed
+// hotspot leaves its slot empty; callers refill it with promoteLocked, or
+// with rebuild after removing several keys. The caller must hold s.mu.
+func (s *shard[K]) removeLocked(key K) bool {
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		return false
+	}
+	if kf.Index >= 0 {
+		heap.Remove(&s.minHeap, kf.Index)
+	}
+	s.forgetLocked(key)
+	s.keyCount.Store(int64(len(s.keyFreqs)))
+	return true
+}
+
+// bestOutsideLocked returns the highest ranked eligible key outside the
+// heap, or nil if there is none. The caller must hold s.mu.
+func (s *shard[K]) bestOutsideLocked() *KeyFreqOf[K] {
+	var best *KeyFreqOf[K]
+	for _, kf := range s.keyFreqs {
+		if kf.Index < 0 && s.eligible(kf.Key) && (best == nil || ranksBelow(best, kf)) {
+			best = kf
+		}
+	}
+	return best
+}
+
+// promoteLocked fills a free heap slot with the best key outside the heap.
+// The caller must hold s.mu.
+func (s *shard[K]) promoteLocked() {
+	if len(s.minHeap) >= s.topN {
+		return
+	}
+	if best := s.bestOutsideLocked(); best != nil {
+		heap.Push(&s.minHeap, best)
+	}
+}
+
+// forgetLocked deletes key's count and per-key state, leaving the heap and
+// keyCount to the caller, who must hold s.mu.
+func (s *shard[K]) forgetLocked(key K) {
+	delete(s.keyFreqs, key)
+	if s.window != nil {
+		s.window.forget(key)
+	}
+	delete(s.weights, key)
+	delete(s.observations, key)
+	if s.history != nil {
+		s.history.forget(key)
+	}
+}
 
-func processKeyFreq(tShard *shard, kf *KeyFreq) {
+// pruneBelow deletes every key with a frequency below min, reporting how
+// many were deleted. Pruned hotspots are filtered out of the heap, which is
+// then re-heapified once. No key outside the heap needs promoting: any that
+// outranked a pruned hotspot would have been admitted in its place.
+func (s *shard[K]) pruneBelow(min int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := 0
+	for key, kf := range s.keyFreqs {
+		if kf.Frequency < min {
+			s.forgetLocked(key)
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return 0
+	}
+	s.keyCount.Store(int64(len(s.keyFreqs)))
+
+	kept := s.minHeap[:0]
+	for _, kf := range s.minHeap {
+		if kf.Frequency < min {
+			kf.Index = -1
+			continue
+		}
+		kf.Index = len(kept)
+		kept = append(kept, kf)
+	}
+	clear(s.minHeap[len(kept):])
+	s.minHeap = kept
+	heap.Init(&s.minHeap)
+	return pruned
+}
 
+// decrement lowers key's frequency by one, removing the key once its
+// frequency reaches zero. Keys that are not tracked are ignored. A hotspot
+// that drops below the best key outside the heap swaps places with it.
+func (s *shard[K]) decrement(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		return
+	}
+	kf.Frequency--
+	if s.weights != nil {
+		s.weights[key]--
+	}
+	if s.floatWeights {
+		kf.Weight--
+		kf.Frequency = int(math.Round(kf.Weight))
+	}
+	hot := kf.Index >= 0
+	if kf.Frequency <= 0 {
+		s.removeLocked(key)
+		if hot {
+			s.promoteLocked()
+		}
+		return
+	}
+	if !hot {
+		return
+	}
+	heap.Fix(&s.minHeap, kf.Index)
+	// Every other hotspot still outranks every key outside the heap, so
+	// only key itself can have fallen below the best of them
+	if best := s.bestOutsideLocked(); best != nil && ranksBelow(kf, best) {
+		heap.Remove(&s.minHeap, kf.Index)
+		heap.Push(&s.minHeap, best)
+		s.demoteLocked(kf)
+	}
+}
+
+// helper functions
+
+// processKeyFreq admits kf, which must already be in keyFreqs, into the
+// shard's heap if there is room or it outranks the current minimum,
+// reporting whether it was admitted and which key it evicted. A key tying
+// the minimum's frequency only displaces it if its key is smaller, the
+// order GetHotspots uses, so equally frequent keys don't evict each other
+// back and forth. The evicted minimum stays in keyFreqs so its count is not
+// lost, and is remembered if the shard keeps a history.
+func processKeyFreq[K comparable](tShard *shard[K], kf *KeyFreqOf[K]) (admitted bool, evicted *KeyFreqOf[K]) {
 	if len(tShard.minHeap) < tShard.topN {
 		heap.Push(&tShard.minHeap, kf)
-		tShard.keyFreqs[kf.Key] = kf
-	} else if tShard.minHeap[0].Frequency <= kf.Frequency {
-		minKey := heap.Pop(&tShard.minHeap).(*KeyFreq)
-		delete(tShard.keyFreqs, minKey.Key)
+	} else if len(tShard.minHeap) > 0 && ranksBelow(tShard.minHeap[0], kf) {
+		evicted = heap.Pop(&tShard.minHeap).(*KeyFreqOf[K])
 		heap.Push(&tShard.minHeap, kf)
-		tShard.keyFreqs[kf.Key] = kf
+		tShard.demoteLocked(evicted)
+	} else {
+		return false, nil
 	}
+	tShard.observeFrequency(kf.Frequency)
+	return true, evicted
 }
@@ -1,10 +1,17 @@
 package htracker
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestHotspotTracker tests the functionality of the HotspotTracker.
@@ -77,6 +84,105 @@ func TestHotspotTrackerEnhancedConcurrency(t *testing.T) {
 	}
 }
 
+// TestHotspotTrackerCacheConcurrency exercises the cached read path against
+// concurrent writes. Run it with -race to catch unsynchronised cache access.
+func TestHotspotTrackerCacheConcurrency(t *testing.T) {
+	ht := NewHotspotTracker(5, 4).WithCache(time.Millisecond)
+	defer ht.Close()
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				ht.RecordRequest(keys[rand.Intn(len(keys))])
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				ht.GetHotspots()
+				ht.IsHotspot(keys[rand.Intn(len(keys))])
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for cached reads, possible deadlock")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 5 {
+		t.Errorf("expected 5 hotspots, got %v", hotspots)
+	}
+}
+
+// TestHotspotTrackerCacheTinyInterval hammers the cache while the ticker
+// constantly invalidates it. Run it with -race.
+func TestHotspotTrackerCacheTinyInterval(t *testing.T) {
+	ht := NewHotspotTracker(3, 4).WithCache(time.Microsecond)
+	defer ht.Close()
+
+	for _, key := range []string{"a", "a", "a", "b", "b", "c", "d"} {
+		ht.RecordRequest(key)
+	}
+	// Prime the cache so readers that lose a rebuild race never see the
+	// empty snapshot from before anything was recorded
+	ht.GetHotspots()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if hotspots := ht.GetHotspots(); len(hotspots) != 3 {
+					t.Errorf("expected 3 hotspots, got %v", hotspots)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHotspotTrackerCacheSingleRebuild(t *testing.T) {
+	var rebuilds atomic.Int64
+	ht := NewHotspotTracker(3, 4).
+		WithAggregationObserver(func(AggregateStats) { rebuilds.Add(1) }).
+		WithCache(time.Hour)
+	defer ht.Close()
+
+	ht.RecordRequest("a")
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ht.GetHotspots()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := rebuilds.Load(); n != 1 {
+		t.Errorf("expected exactly 1 cache rebuild, got %d", n)
+	}
+}
+
 func TestHotspotTrackerEdgeCases(t *testing.T) {
 	// Empty tracker
 	ht := NewHotspotTracker(3, 4)
@@ -98,7 +204,9 @@ func TestHotspotTrackerEdgeCases(t *testing.T) {
 		ht.RecordRequest(key)
 	}
 
-	expected := []string{"c", "d", "a"}
+	// Ties rank the smaller key higher, so "b" and "c" make the cut behind
+	// the more frequent "a"
+	expected := []string{"a", "b", "c"}
 	actual := ht.GetHotspots()
 	if len(actual) != 3 {
 		t.Errorf("expected 3 hotspots, got %d", len(actual))
@@ -110,6 +218,426 @@ func TestHotspotTrackerEdgeCases(t *testing.T) {
 	}
 }
 
+func TestGetFrequency(t *testing.T) {
+	ht := NewHotspotTracker(5, 2)
+
+	counts := map[string]int{"a": 7, "b": 3, "c": 1}
+	for key, n := range counts {
+		for i := 0; i < n; i++ {
+			ht.RecordRequest(key)
+		}
+	}
+
+	for key, expected := range counts {
+		freq, ok := ht.GetFrequency(key)
+		if !ok {
+			t.Errorf("expected %q to be tracked", key)
+		}
+		if freq != expected {
+			t.Errorf("expected %q to have frequency %d, got %d", key, expected, freq)
+		}
+	}
+
+	if freq, ok := ht.GetFrequency("missing"); ok || freq != 0 {
+		t.Errorf("expected missing key to report (0, false), got (%d, %v)", freq, ok)
+	}
+
+	// An evicted key keeps its count
+	ht = NewHotspotTracker(1, 1)
+	ht.RecordRequest("x")
+	ht.RecordRequest("y")
+	if freq, ok := ht.GetFrequency("x"); !ok || freq != 1 {
+		t.Errorf("expected evicted key 'x' to report (1, true), got (%d, %v)", freq, ok)
+	}
+}
+
+// TestEvictedKeyKeepsCount records a key many times, lets a burst of another
+// key evict it, then records it again and expects the cumulative count.
+func TestEvictedKeyKeepsCount(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+
+	for i := 0; i < 10; i++ {
+		ht.RecordRequest("a")
+	}
+	for i := 0; i < 20; i++ {
+		ht.RecordRequest("b")
+	}
+	if ht.IsHotspot("a") {
+		t.Fatal("expected the burst of 'b' to evict 'a'")
+	}
+
+	for i := 0; i < 15; i++ {
+		ht.RecordRequest("a")
+	}
+	if freq, _ := ht.GetFrequency("a"); freq != 25 {
+		t.Errorf("expected 'a' to have cumulative frequency 25, got %d", freq)
+	}
+	hotspots := ht.GetHotspotsWithCounts()
+	if len(hotspots) != 1 || hotspots[0].Key != "a" || hotspots[0].Frequency != 25 {
+		t.Errorf("expected [a:25], got %v", hotspots)
+	}
+	if freq, _ := ht.GetFrequency("b"); freq != 20 {
+		t.Errorf("expected evicted 'b' to keep frequency 20, got %d", freq)
+	}
+}
+
+// TestIsHotspotSeenButNotHot checks that a key counted outside the top N is
+// not a hotspot, in the shard as well as across shards
+func TestIsHotspotSeenButNotHot(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	ht.RecordRequestN("hot", 5)
+	ht.RecordRequestN("seen", 2)
+
+	if freq, ok := ht.GetFrequency("seen"); !ok || freq != 2 {
+		t.Fatalf("expected seen to be counted with 2, got %d, %v", freq, ok)
+	}
+	s := ht.shards[0]
+	if s.IsHotspot("seen") || ht.IsHotspot("seen") {
+		t.Error("expected a key outside the top N not to be a hotspot")
+	}
+	if !s.IsHotspot("hot") || !ht.IsHotspot("hot") {
+		t.Error("expected the top key to be a hotspot")
+	}
+	if s.IsHotspot("unseen") || ht.IsHotspot("unseen") {
+		t.Error("expected an unseen key not to be a hotspot")
+	}
+}
+
+// TestHotspotTrackerReranking interleaves increments of two close competitors
+// and checks the ranking and the shard heaps after every single increment.
+func TestHotspotTrackerReranking(t *testing.T) {
+	for _, numShards := range []int{1, 4} {
+		ht := NewHotspotTracker(3, numShards)
+		ht.RecordRequest("c")
+		for i := 0; i < 5; i++ {
+			ht.RecordRequest("a")
+			ht.RecordRequest("b")
+		}
+
+		steps := []struct {
+			key      string
+			expected []KeyFreq
+		}{
+			{"a", []KeyFreq{{Key: "a", Frequency: 6}, {Key: "b", Frequency: 5}, {Key: "c", Frequency: 1}}},
+			{"b", []KeyFreq{{Key: "a", Frequency: 6}, {Key: "b", Frequency: 6}, {Key: "c", Frequency: 1}}},
+			{"b", []KeyFreq{{Key: "b", Frequency: 7}, {Key: "a", Frequency: 6}, {Key: "c", Frequency: 1}}},
+			{"a", []KeyFreq{{Key: "a", Frequency: 7}, {Key: "b", Frequency: 7}, {Key: "c", Frequency: 1}}},
+			{"a", []KeyFreq{{Key: "a", Frequency: 8}, {Key: "b", Frequency: 7}, {Key: "c", Frequency: 1}}},
+			{"c", []KeyFreq{{Key: "a", Frequency: 8}, {Key: "b", Frequency: 7}, {Key: "c", Frequency: 2}}},
+			{"b", []KeyFreq{{Key: "a", Frequency: 8}, {Key: "b", Frequency: 8}, {Key: "c", Frequency: 2}}},
+			{"b", []KeyFreq{{Key: "b", Frequency: 9}, {Key: "a", Frequency: 8}, {Key: "c", Frequency: 2}}},
+		}
+		for i, step := range steps {
+			ht.RecordRequest(step.key)
+
+			for si, s := range ht.shards {
+				for j, kf := range s.minHeap {
+					if kf.Index != j {
+						t.Errorf("%d shards, step %d: shard %d key %q has index %d at position %d", numShards, i, si, kf.Key, kf.Index, j)
+					}
+					if parent := (j - 1) / 2; j > 0 && s.minHeap[parent].Frequency > kf.Frequency {
+						t.Errorf("%d shards, step %d: shard %d heap property violated at %d", numShards, i, si, j)
+					}
+				}
+			}
+
+			actual := ht.GetHotspotsWithCounts()
+			if len(actual) != len(step.expected) {
+				t.Fatalf("%d shards, step %d: expected %v, got %v", numShards, i, step.expected, actual)
+			}
+			for j := range step.expected {
+				if actual[j].Key != step.expected[j].Key || actual[j].Frequency != step.expected[j].Frequency {
+					t.Errorf("%d shards, step %d: expected %v, got %v", numShards, i, step.expected, actual)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestGetHotspotsWithCounts(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+
+	keys := []string{"a", "b", "c", "a", "a", "b", "d", "d", "d", "d", "e"}
+	for _, key := range keys {
+		ht.RecordRequest(key)
+	}
+
+	expected := []KeyFreq{{Key: "d", Frequency: 4}, {Key: "a", Frequency: 3}, {Key: "b", Frequency: 2}}
+	actual := ht.GetHotspotsWithCounts()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d hotspots, got %v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i].Key != expected[i].Key || actual[i].Frequency != expected[i].Frequency {
+			t.Errorf("position %d: expected %s=%d, got %s=%d", i,
+				expected[i].Key, expected[i].Frequency, actual[i].Key, actual[i].Frequency)
+		}
+	}
+
+	// Mutating the result must not affect the tracker
+	actual[0].Frequency = 100
+	if freq, _ := ht.GetFrequency("d"); freq != 4 {
+		t.Errorf("expected tracker frequency of 'd' to stay 4, got %d", freq)
+	}
+}
+
+func TestRecordRequestN(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+
+	ht.RecordRequest("a")
+	ht.RecordRequestN("a", 4)
+	ht.RecordRequestN("b", 10)
+	ht.RecordRequest("b")
+	ht.RecordRequest("c")
+	ht.RecordRequestN("c", 0)
+	ht.RecordRequestN("c", -3)
+
+	expected := []KeyFreq{{Key: "b", Frequency: 11}, {Key: "a", Frequency: 5}, {Key: "c", Frequency: 1}}
+	actual := ht.GetHotspotsWithCounts()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i].Key != expected[i].Key || actual[i].Frequency != expected[i].Frequency {
+			t.Errorf("position %d: expected %s=%d, got %s=%d", i,
+				expected[i].Key, expected[i].Frequency, actual[i].Key, actual[i].Frequency)
+		}
+	}
+	if total := ht.TotalRequests(); total != 17 {
+		t.Errorf("expected total weight 17, got %d", total)
+	}
+
+	// A heavy newcomer displaces the lightest hotspot in a full shard
+	ht = NewHotspotTracker(2, 1)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+	ht.RecordRequestN("c", 4)
+	if ht.IsHotspot("b") || !ht.IsHotspot("c") {
+		t.Errorf("expected 'c' to evict 'b', got %v", ht.GetHotspots())
+	}
+}
+
+func TestRemoveKey(t *testing.T) {
+	ht := NewHotspotTracker(2, 1)
+
+	for _, key := range []string{"a", "a", "a", "b", "b", "c"} {
+		ht.RecordRequest(key)
+	}
+
+	// "c" was never admitted since the heap was full, but its count is kept
+	if !ht.RemoveKey("c") {
+		t.Error("expected removing counted key 'c' to report true")
+	}
+	if ht.RemoveKey("missing") {
+		t.Error("expected removing nonexistent key to report false")
+	}
+
+	if !ht.RemoveKey("a") {
+		t.Fatal("expected removing tracked key 'a' to report true")
+	}
+	if ht.IsHotspot("a") {
+		t.Error("expected 'a' to no longer be a hotspot")
+	}
+	if _, ok := ht.GetFrequency("a"); ok {
+		t.Error("expected 'a' to no longer be tracked")
+	}
+	if ht.RemoveKey("a") {
+		t.Error("expected removing 'a' twice to report false")
+	}
+
+	hotspots := ht.GetHotspots()
+	if len(hotspots) != 1 || hotspots[0] != "b" {
+		t.Errorf("expected ['b'], got %v", hotspots)
+	}
+
+	// The freed slot can be reused
+	ht.RecordRequest("d")
+	if !ht.IsHotspot("d") {
+		t.Error("expected 'd' to take the freed slot")
+	}
+
+	// An evicted key keeps its count until removed
+	ht = NewHotspotTracker(1, 1)
+	ht.RecordRequest("x")
+	ht.RecordRequest("y")
+	if !ht.RemoveKey("x") {
+		t.Error("expected removing evicted key 'x' to report true")
+	}
+	if _, ok := ht.GetFrequency("x"); ok {
+		t.Error("expected 'x' to be forgotten")
+	}
+}
+
+func TestRemoveKeyPromotes(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+	ht.RecordRequestN("c", 1)
+
+	ht.RemoveKey("a")
+	if hotspots := ht.GetHotspots(); !slices.Equal(hotspots, []string{"b"}) {
+		t.Errorf("expected 'b' to take the freed slot, got %v", hotspots)
+	}
+}
+
+func TestRemoveKeyInvalidatesCache(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithCache(time.Hour)
+	defer ht.Close()
+
+	ht.RecordRequest("a")
+	ht.RecordRequest("b")
+	if !ht.IsHotspot("a") {
+		t.Fatal("expected 'a' to be a hotspot")
+	}
+
+	ht.RemoveKey("a")
+	if ht.IsHotspot("a") {
+		t.Error("expected cached hotspots to reflect the removal")
+	}
+}
+
+func TestWithMaxStaleness(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(2, 2).WithClock(clock).WithMaxStaleness(time.Minute).WithCache(time.Hour)
+	defer ht.Close()
+
+	ht.RecordRequest("a")
+	ht.GetHotspots() // build the cache
+	ht.RecordRequestN("b", 2)
+
+	clock.Advance(time.Minute)
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected the cache to be served up to maxStaleness, got %v", got)
+	}
+
+	clock.Advance(time.Second)
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("expected a stale cache to be rebuilt on read, got %v", got)
+	}
+	if got := ht.Metrics().CacheRebuilds; got != 2 {
+		t.Errorf("expected 2 cache rebuilds, got %d", got)
+	}
+
+	// The rebuilt cache is fresh again
+	ht.RecordRequestN("c", 5)
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("expected the rebuilt cache to be served, got %v", got)
+	}
+}
+
+func TestPruneBelow(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithCache(time.Hour)
+	defer ht.Close()
+
+	counts := map[string]int{"a": 6, "b": 5, "c": 4, "d": 3, "e": 2, "f": 1, "g": 1}
+	ht.AddCounts(counts)
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("expected [a b c] before pruning, got %v", got)
+	}
+
+	if pruned := ht.PruneBelow(4); pruned != 4 {
+		t.Errorf("expected 4 keys pruned, got %d", pruned)
+	}
+	for key, count := range counts {
+		_, tracked := ht.GetFrequency(key)
+		if tracked != (count >= 4) {
+			t.Errorf("key %q with count %d: tracked = %v", key, count, tracked)
+		}
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("expected [a b c] after pruning, got %v", got)
+	}
+
+	// Pruning hotspots leaves a valid heap with free slots
+	if pruned := ht.PruneBelow(6); pruned != 2 {
+		t.Errorf("expected 2 keys pruned, got %d", pruned)
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected cached hotspots to reflect the pruning, got %v", got)
+	}
+	for _, shard := range ht.shards {
+		if err := shard.checkRoot(); err != nil {
+			t.Error(err)
+		}
+		for _, kf := range shard.minHeap {
+			if err := shard.checkIndex(kf); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ht.RecordRequest("h")
+	if !ht.shards[ht.shardIndex("h")].IsHotspot("h") {
+		t.Error("expected 'h' to take a freed slot")
+	}
+
+	if pruned := ht.PruneBelow(1); pruned != 0 {
+		t.Errorf("expected nothing pruned below 1, got %d", pruned)
+	}
+}
+
+func TestKeysAtFrequency(t *testing.T) {
+	ht := NewHotspotTracker(10, 4)
+
+	keys := []string{"e", "b", "d", "a", "a", "c", "c", "d", "e", "f", "f", "f"}
+	for _, key := range keys {
+		ht.RecordRequest(key)
+	}
+
+	tests := []struct {
+		freq     int
+		expected []string
+	}{
+		{1, []string{"b"}},
+		{2, []string{"a", "c", "d", "e"}},
+		{3, []string{"f"}},
+		{4, nil},
+	}
+	for _, tt := range tests {
+		actual := ht.KeysAtFrequency(tt.freq)
+		if len(actual) != len(tt.expected) {
+			t.Errorf("frequency %d: expected %v, got %v", tt.freq, tt.expected, actual)
+			continue
+		}
+		for i := range tt.expected {
+			if actual[i] != tt.expected[i] {
+				t.Errorf("frequency %d: expected %v, got %v", tt.freq, tt.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestGetAllFrequencies(t *testing.T) {
+	ht := NewHotspotTracker(2, 4)
+	for i := 0; i < 20; i++ {
+		for j := 0; j <= i%5; j++ {
+			ht.RecordRequest(fmt.Sprintf("key%d", i))
+		}
+	}
+
+	freqs := ht.GetAllFrequencies()
+	if len(freqs) != 20 {
+		t.Fatalf("expected all 20 keys, got %d", len(freqs))
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if freqs[key] != i%5+1 {
+			t.Errorf("%s: expected %d, got %d", key, i%5+1, freqs[key])
+		}
+	}
+	for _, kf := range ht.GetHotspotsWithCounts() {
+		if freqs[kf.Key] != kf.Frequency {
+			t.Errorf("hotspot %s: expected %d, got %d", kf.Key, kf.Frequency, freqs[kf.Key])
+		}
+	}
+
+	freqs["key0"] = 100
+	if got, _ := ht.GetFrequency("key0"); got != 1 {
+		t.Errorf("expected the dump to be a copy, key0 now has %d", got)
+	}
+}
+
 func generateKey() string {
 	randomChar := rand.Intn(26) // Generates a random integer in [0, 25]
 	return fmt.Sprintf("a%d", randomChar)
@@ -136,6 +664,25 @@ func BenchmarkRecordRequest(b *testing.B) {
 	ht.GetHotspots()
 }
 
+// BenchmarkRecordRequestShardCount compares a power-of-two shard count,
+// where shardIndex masks, with one just below it, where it divides.
+func BenchmarkRecordRequestShardCount(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = generateKey()
+	}
+
+	for _, numShards := range []int{7, 8} {
+		b.Run(fmt.Sprintf("shards=%d", numShards), func(b *testing.B) {
+			ht := NewHotspotTracker(100, numShards)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ht.RecordRequest(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
 // BenchmarkGetHotspots benchmarks the GetHotspots method.
 func BenchmarkGetHotspots(b *testing.B) {
 	ht := NewHotspotTracker(100, 4)
@@ -152,6 +699,45 @@ func BenchmarkGetHotspots(b *testing.B) {
 	}
 }
 
+// BenchmarkGetHotspotsInto benchmarks GetHotspotsInto with a reused buffer
+// and a cached aggregate, which should not allocate.
+func BenchmarkGetHotspotsInto(b *testing.B) {
+	ht := NewHotspotTracker(100, 4).WithCache(time.Hour)
+	defer ht.Close()
+
+	for i := 0; i < 1000000; i++ {
+		ht.RecordRequest(generateKey())
+	}
+	dst := ht.GetHotspotsInto(nil)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = ht.GetHotspotsInto(dst)
+	}
+}
+
+// BenchmarkGetHotspotsParallel benchmarks concurrent readers of a cached
+// aggregate
+func BenchmarkGetHotspotsParallel(b *testing.B) {
+	ht := NewHotspotTracker(100, 4).WithCache(time.Hour)
+	defer ht.Close()
+
+	for i := 0; i < 100000; i++ {
+		ht.RecordRequest(generateKey())
+	}
+	ht.GetHotspots()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var dst []string
+		for pb.Next() {
+			dst = ht.GetHotspotsInto(dst)
+		}
+	})
+}
+
 func BenchmarkIsHotspot(b *testing.B) {
 	ht := NewHotspotTracker(100, 4)
 
@@ -215,3 +801,767 @@ func BenchmarkEnhancedConcurrentAccessSharded(b *testing.B) {
 	}
 	wg.Wait()
 }
+
+// populateForAggregation records at least 1000 distinct keys into ht. When
+// concentrated is set the 100 hottest keys all live in shard 0 and every other
+// key is cold, otherwise frequencies are spread evenly over all shards.
+func populateForAggregation(ht *HotspotTracker, concentrated bool) {
+	hot := 0
+	for i := 0; i < 1000 || (concentrated && hot < 100); i++ {
+		key := fmt.Sprintf("key%d", i)
+		freq := 1 + (i*7919)%1000
+		if concentrated {
+			freq = 1 + i%5
+			if hot < 100 && ht.shardIndex(key) == 0 {
+				freq = 1000 + hot
+				hot++
+			}
+		}
+		for j := 0; j < freq; j++ {
+			ht.RecordRequest(key)
+		}
+	}
+}
+
+// BenchmarkAggregateConcentrated benchmarks aggregation when all hotspots share a shard.
+func BenchmarkAggregateConcentrated(b *testing.B) {
+	ht := NewHotspotTracker(100, 8)
+	populateForAggregation(ht, true)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ht.aggregateShards(context.Background())
+	}
+}
+
+// BenchmarkAggregateUniform benchmarks aggregation when hotspots are spread over all shards.
+func BenchmarkAggregateUniform(b *testing.B) {
+	ht := NewHotspotTracker(100, 8)
+	populateForAggregation(ht, false)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ht.aggregateShards(context.Background())
+	}
+}
+
+func TestHotspotTrackerOf(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		ht := NewHotspotTrackerOf(2, 4, FNV1a)
+		for _, key := range []string{"a", "a", "a", "b", "b", "c"} {
+			ht.RecordRequest(key)
+		}
+		expected := []KeyFreq{{Key: "a", Frequency: 3}, {Key: "b", Frequency: 2}}
+		assertKeyFreqs(t, ht.GetHotspotsWithCounts(), expected)
+		if freq, ok := ht.GetFrequency("c"); !ok || freq != 1 {
+			t.Errorf("expected 'c' to report (1, true), got (%d, %v)", freq, ok)
+		}
+	})
+
+	t.Run("int64", func(t *testing.T) {
+		hash := func(id int64) uint32 { return uint32(id) ^ uint32(id>>32) }
+		ht := NewHotspotTrackerOf(2, 4, hash)
+		for _, id := range []int64{42, 42, 42, 7, 7, 1 << 40, 1 << 40} {
+			ht.RecordRequest(id)
+		}
+		ht.RecordRequestN(3, 1)
+
+		// 7 and 1<<40 tie, and ties are broken by key
+		expected := []KeyFreqOf[int64]{{Key: 42, Frequency: 3}, {Key: 7, Frequency: 2}}
+		assertKeyFreqs(t, ht.GetHotspotsWithCounts(), expected)
+		if !ht.IsHotspot(42) || ht.IsHotspot(3) {
+			t.Errorf("expected 42 but not 3 to be a hotspot, got %v", ht.GetHotspots())
+		}
+		if keys := ht.KeysAtFrequency(2); len(keys) != 2 || keys[0] != 7 || keys[1] != 1<<40 {
+			t.Errorf("expected [7 %d] at frequency 2, got %v", int64(1<<40), keys)
+		}
+		if !ht.RemoveKey(42) || ht.IsHotspot(42) {
+			t.Error("expected 42 to be removed")
+		}
+	})
+}
+
+// assertKeyFreqs compares keys and frequencies, ignoring heap indexes
+func assertKeyFreqs[K comparable](t *testing.T, got, expected []KeyFreqOf[K]) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i].Key != expected[i].Key || got[i].Frequency != expected[i].Frequency {
+			t.Errorf("expected %v at position %d, got %v", expected[i], i, got[i])
+		}
+	}
+}
+
+func TestMinHeapOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := &MinHeap{}
+	for i := 0; i < 1000; i++ {
+		heap.Push(h, &KeyFreq{Key: fmt.Sprint(i), Frequency: rng.Intn(100)})
+		// Interleave pops so the heap is exercised at every size
+		if i%3 == 0 {
+			heap.Pop(h)
+		}
+	}
+	for i, kf := range *h {
+		if kf.Index != i {
+			t.Fatalf("key %q has index %d, expected %d", kf.Key, kf.Index, i)
+		}
+	}
+
+	prev := -1
+	for h.Len() > 0 {
+		kf := heap.Pop(h).(*KeyFreq)
+		if kf.Frequency < prev {
+			t.Fatalf("popped frequency %d after %d", kf.Frequency, prev)
+		}
+		if kf.Index != -1 {
+			t.Fatalf("expected popped key %q to have index -1, got %d", kf.Key, kf.Index)
+		}
+		prev = kf.Frequency
+	}
+}
+
+// TestAggregateKeepsShardIndexes aggregates and reads hotspots, which copy
+// and pop heap entries, and checks every live shard's Index fields still
+// match their heap positions.
+func TestAggregateKeepsShardIndexes(t *testing.T) {
+	ht := NewHotspotTracker(4, 3)
+	for i := 0; i < 50; i++ {
+		ht.RecordRequestN(fmt.Sprint("key", i%20), i)
+	}
+
+	for i := 0; i < 3; i++ {
+		ht.GetHotspots()
+		ht.GetHotspotsWithCounts()
+		ht.aggregate().GetHotspots()
+	}
+
+	for si, s := range ht.shards {
+		if len(s.minHeap) != 4 {
+			t.Fatalf("expected shard %d to hold 4 hotspots, got %d", si, len(s.minHeap))
+		}
+		for i, kf := range s.minHeap {
+			if kf.Index != i {
+				t.Errorf("shard %d: key %q has index %d, expected %d", si, kf.Key, kf.Index, i)
+			}
+		}
+		if err := s.checkRoot(); err != nil {
+			t.Errorf("shard %d: %v", si, err)
+		}
+	}
+}
+
+func TestPeriodicTask(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(2, 4).WithClock(clock).WithCache(time.Second)
+	defer ht.Close()
+
+	// Tasks run in registration order, so once the last task has run for a
+	// tick the earlier ones have too
+	var everyTick, everyThird atomic.Int64
+	ht.withPeriodicTask(3*time.Second/2, func(*shard[string]) { everyThird.Add(1) })
+	ht.withPeriodicTask(time.Second/2, func(*shard[string]) { everyTick.Add(1) })
+
+	// The ticker now ticks every half second and runs each task once per
+	// shard when its interval is up
+	for tick := 1; tick <= 6; tick++ {
+		clock.Advance(time.Second / 2)
+		for everyTick.Load() != int64(4*tick) {
+			runtime.Gosched()
+		}
+		if got, expected := everyThird.Load(), int64(4*(tick/3)); got != expected {
+			t.Errorf("tick %d: expected the slower task to have run %d times, got %d", tick, expected, got)
+		}
+	}
+}
+
+func TestGetHotspotsOrder(t *testing.T) {
+	ht := NewHotspotTracker(4, 3)
+	counts := map[string]int{"a": 1, "b": 7, "c": 3, "d": 5, "e": 2, "f": 7}
+	for key, n := range counts {
+		ht.RecordRequestN(key, n)
+	}
+
+	// Most frequent first, ties broken by key
+	expected := []string{"b", "f", "d", "c"}
+	if got := ht.GetHotspots(); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	for i, s := range ht.shards {
+		hotspots := s.GetHotspots()
+		for j := 1; j < len(hotspots); j++ {
+			prev, cur := counts[hotspots[j-1]], counts[hotspots[j]]
+			if prev < cur || prev == cur && hotspots[j-1] > hotspots[j] {
+				t.Errorf("shard %d: expected descending order, got %v", i, hotspots)
+			}
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	ht := NewHotspotTracker(4, 3).WithCache(time.Hour)
+	defer ht.Close()
+	for key, n := range map[string]int{"a": 1, "b": 7, "c": 3, "d": 5, "e": 2, "f": 7} {
+		ht.RecordRequestN(key, n)
+	}
+
+	for key, expected := range map[string]int{"b": 1, "f": 2, "d": 3, "c": 4} {
+		if rank, ok := ht.Rank(key); !ok || rank != expected {
+			t.Errorf("expected %q to rank %d, got (%d, %v)", key, expected, rank, ok)
+		}
+	}
+	for _, key := range []string{"a", "e", "missing"} {
+		if rank, ok := ht.Rank(key); ok {
+			t.Errorf("expected %q not to be ranked, got %d", key, rank)
+		}
+	}
+}
+
+func TestPooledAggregateNotAliased(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordRequestN("a", 3)
+	ht.RecordRequestN("b", 2)
+
+	hotspots := ht.GetHotspots()
+	counts := ht.GetHotspotsWithCounts()
+
+	// Later reads rebuild the pooled aggregate with different keys
+	ht.RemoveKey("a")
+	ht.RemoveKey("b")
+	ht.RecordRequestN("c", 5)
+	ht.RecordRequestN("d", 4)
+	for i := 0; i < 10; i++ {
+		ht.GetHotspots()
+		ht.IsHotspot("c")
+	}
+
+	if fmt.Sprint(hotspots) != "[a b]" {
+		t.Errorf("expected earlier hotspots to stay [a b], got %v", hotspots)
+	}
+	if counts[0].Key != "a" || counts[0].Frequency != 3 || counts[1].Key != "b" || counts[1].Frequency != 2 {
+		t.Errorf("expected earlier counts to stay [a:3 b:2], got %v", counts)
+	}
+}
+
+func TestWithMinFrequency(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithMinFrequency(5)
+	ht.RecordRequestN("hot", 10)
+	ht.RecordRequestN("warm", 5)
+	ht.RecordRequestN("cold", 2) // fills the last slot but is below the minimum
+
+	if got := ht.GetHotspots(); fmt.Sprint(got) != "[hot warm]" {
+		t.Errorf("expected [hot warm], got %v", got)
+	}
+	if ht.IsHotspot("cold") {
+		t.Error("expected 'cold' not to be a hotspot below the minimum frequency")
+	}
+	if freq, ok := ht.GetFrequency("cold"); !ok || freq != 2 {
+		t.Errorf("expected 'cold' to stay tracked at 2, got (%d, %v)", freq, ok)
+	}
+
+	ht.RecordRequestN("cold", 3)
+	if !ht.IsHotspot("cold") {
+		t.Error("expected 'cold' to become a hotspot once it reaches the minimum")
+	}
+}
+
+func TestWithAdaptiveThreshold(t *testing.T) {
+	record := func(ht *HotspotTracker, freqs map[string]int) {
+		for key, n := range freqs {
+			ht.RecordRequestN(key, n)
+		}
+	}
+
+	// Quiet: a mean of 2.8, so hot's 10 clears twice the mean
+	quiet := NewHotspotTracker(5, 2).WithAdaptiveThreshold(2)
+	record(quiet, map[string]int{"hot": 10, "a": 1, "b": 1, "c": 1, "d": 1})
+	if got := quiet.GetHotspots(); len(got) != 1 || got[0] != "hot" {
+		t.Errorf("expected [hot] at low traffic, got %v", got)
+	}
+	if !quiet.IsHotspot("hot") {
+		t.Error("expected hot to be a hotspot at low traffic")
+	}
+
+	// Busy: the same 10 requests are below a mean of 42
+	busy := NewHotspotTracker(5, 2).WithAdaptiveThreshold(2)
+	record(busy, map[string]int{"hot": 10, "a": 50, "b": 50, "c": 50, "d": 50})
+	if busy.IsHotspot("hot") {
+		t.Error("expected hot not to be a hotspot at high traffic")
+	}
+	if got := busy.GetHotspots(); len(got) != 0 {
+		t.Errorf("expected no key to exceed twice the mean, got %v", got)
+	}
+	if freq, _ := busy.GetFrequency("hot"); freq != 10 {
+		t.Errorf("expected hot to stay tracked with 10, got %d", freq)
+	}
+}
+
+func TestKeyFreqString(t *testing.T) {
+	if got := (KeyFreq{Key: "a", Frequency: 5, Index: 2}).String(); got != "key=a freq=5" {
+		t.Errorf("expected 'key=a freq=5', got %q", got)
+	}
+	if got := fmt.Sprint(KeyFreqOf[int64]{Key: 42, Frequency: 1}); got != "key=42 freq=1" {
+		t.Errorf("expected 'key=42 freq=1', got %q", got)
+	}
+}
+
+func TestEmptyHeap(t *testing.T) {
+	var h MinHeap
+	if item := h.Pop(); item != nil {
+		t.Errorf("expected Pop on an empty heap to return nil, got %v", item)
+	}
+
+	// A shard with no room never admits anything
+	s := newShard[string](0)
+	if _, err := s.record("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if hotspots := s.GetHotspots(); len(hotspots) != 0 {
+		t.Errorf("expected no hotspots from a shard with no room, got %v", hotspots)
+	}
+	if s.floor() != 0 {
+		t.Errorf("expected a floor of 0, got %d", s.floor())
+	}
+
+	s = newShard[string](0)
+	s.sketch = newCountMinSketch(16, 2)
+	s.sketchHash = FNV1a
+	if change := s.recordSketch("a", 1); change.admitted != nil || len(s.minHeap) != 0 {
+		t.Errorf("expected a sketch shard with no room not to admit 'a', got %v", s.minHeap)
+	}
+
+	// Emptying a tracker leaves every read working
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordRequest("a")
+	ht.RemoveKey("a")
+	if hotspots := ht.GetHotspots(); len(hotspots) != 0 {
+		t.Errorf("expected no hotspots, got %v", hotspots)
+	}
+	if ht.IsHotspot("a") || ht.HotspotFloor() != 0 || len(ht.GetTopK(3)) != 0 {
+		t.Error("expected reads on an emptied tracker to report nothing")
+	}
+	ht.RecordRequest("b")
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "b" {
+		t.Errorf("expected ['b'] after recording into an emptied tracker, got %v", hotspots)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	ht := NewHotspotTracker(4, 3)
+	for key, n := range map[string]int{"a": 1, "b": 7, "c": 3, "d": 5, "e": 2} {
+		ht.RecordRequestN(key, n)
+	}
+
+	var got []KeyFreq
+	ht.ForEach(func(key string, freq int) bool {
+		got = append(got, KeyFreq{Key: key, Frequency: freq})
+		return true
+	})
+	expected := ht.GetHotspotsWithCounts()
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	var keys []string
+	ht.ForEach(func(key string, freq int) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+	if fmt.Sprint(keys) != "[b d]" {
+		t.Errorf("expected ForEach to stop after [b d], got %v", keys)
+	}
+}
+
+func TestGetHotspotsByShard(t *testing.T) {
+	ht := NewHotspotTracker(2, 4)
+	for i := 0; i < 40; i++ {
+		ht.RecordRequestN(fmt.Sprintf("key%d", i), i%9+1)
+	}
+
+	byShard := ht.GetHotspotsByShard()
+	if len(byShard) != 4 {
+		t.Fatalf("expected 4 shards, got %d", len(byShard))
+	}
+	for i, keys := range byShard {
+		s := ht.shards[i]
+		if len(keys) != len(s.minHeap) {
+			t.Errorf("shard %d: expected %d keys, got %v", i, len(s.minHeap), keys)
+		}
+		for j, key := range keys {
+			kf, ok := s.keyFreqs[key]
+			if !ok || kf.Index < 0 {
+				t.Errorf("shard %d: expected %q to be in the shard's heap", i, key)
+			}
+			if j > 0 && compareRank(s.keyFreqs[keys[j-1]], kf) > 0 {
+				t.Errorf("shard %d: expected descending order, got %v", i, keys)
+			}
+		}
+	}
+}
+
+func TestTieAdmissionWithoutChurn(t *testing.T) {
+	var evictions int
+	ht := NewHotspotTracker(5, 1).OnEvict(func(string, int) { evictions++ })
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%02d", i)
+	}
+	expected := "[key00 key01 key02 key03 key04]"
+
+	for round := 1; round <= 5; round++ {
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, key := range keys {
+			ht.RecordRequest(key)
+		}
+		for i := 0; i < 3; i++ {
+			if got := fmt.Sprint(ht.GetHotspots()); got != expected {
+				t.Fatalf("round %d: expected %s, got %s", round, expected, got)
+			}
+		}
+	}
+
+	// Equally frequent newcomers that rank lower never displace anyone
+	evictions = 0
+	for _, key := range keys {
+		ht.RecordRequestN("z"+key, 5)
+	}
+	if evictions != 0 {
+		t.Errorf("expected no evictions from tied newcomers, got %d", evictions)
+	}
+	if got := fmt.Sprint(ht.GetHotspots()); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestWithFullAggregation(t *testing.T) {
+	for _, full := range []bool{false, true} {
+		ht := NewHotspotTracker(2, 1)
+		if full {
+			ht.WithFullAggregation()
+		}
+		ht.RecordRequestN("a", 5)
+		ht.RecordRequestN("b", 4)
+		ht.RecordRequestN("c", 3) // counted but outside the shard's heap
+
+		// Knock "b" out of the heap behind the tracker's back, as a heap
+		// that fell out of step with its counts would
+		s := ht.shards[0]
+		heap.Remove(&s.minHeap, s.keyFreqs["b"].Index)
+		got := fmt.Sprint(ht.GetHotspots())
+		if full && got != "[a b]" {
+			t.Errorf("expected [a b] with full aggregation, got %s", got)
+		}
+		if !full && got != "[a]" {
+			t.Errorf("expected [a] from the shard heaps alone, got %s", got)
+		}
+	}
+}
+
+// TestCacheSwapConcurrentReaders reads the cache while writers keep
+// invalidating it, for the race detector to check the pointer swaps
+func TestCacheSwapConcurrentReaders(t *testing.T) {
+	ht := NewHotspotTracker(5, 4).WithCache(time.Microsecond)
+	defer ht.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				ht.RecordRequest(fmt.Sprintf("key%d", (w+i)%20))
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			var dst []string
+			for i := 0; i < 2000; i++ {
+				dst = ht.GetHotspotsInto(dst)
+				if len(dst) > 5 {
+					t.Errorf("expected at most 5 hotspots, got %v", dst)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	ht.update.Store(true)
+	if got := ht.GetHotspots(); len(got) != 5 {
+		t.Errorf("expected 5 hotspots after the writers finished, got %v", got)
+	}
+}
+
+func TestGetHotspotsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, cached := range []bool{false, true} {
+		ht := NewHotspotTracker(5, 4).WithFullAggregation()
+		if cached {
+			ht.WithCache(time.Hour)
+		}
+		for i := 0; i < 1000; i++ {
+			ht.RecordRequest(fmt.Sprintf("key%d", i))
+		}
+		ht.RecordRequestN("hot", 10)
+
+		start := time.Now()
+		got, err := ht.GetHotspotsContext(ctx)
+		if !errors.Is(err, context.Canceled) || got != nil {
+			t.Errorf("cached=%v: expected context.Canceled and no hotspots, got %v, %v", cached, got, err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("cached=%v: expected a prompt return, took %v", cached, elapsed)
+		}
+		if ht.Metrics().CacheRebuilds != 0 || ht.aggregations.Load() != 0 {
+			t.Errorf("cached=%v: expected the cancelled aggregation not to count", cached)
+		}
+
+		got, err = ht.GetHotspotsContext(context.Background())
+		if err != nil || len(got) != 5 || got[0] != "hot" {
+			t.Errorf("cached=%v: expected 5 hotspots led by hot, got %v, %v", cached, got, err)
+		}
+		ht.Close()
+	}
+}
+
+func TestGetHotspotsInto(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		ht.RecordRequestN(key, i%3+1)
+	}
+
+	want := ht.GetHotspots()
+	dst := make([]string, 1, 8)
+	dst[0] = "stale"
+	got := ht.GetHotspotsInto(dst)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if &got[0] != &dst[:1][0] {
+		t.Error("expected dst to be reused when it has room")
+	}
+
+	if got := ht.GetHotspotsInto(nil); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v with a nil dst, got %v", want, got)
+	}
+}
+
+func TestGetTopK(t *testing.T) {
+	ht := NewHotspotTracker(10, 3)
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		ht.RecordRequestN(key, i+1)
+	}
+	// Ties are ranked by key
+	ht.RecordRequestN("ff", 6)
+
+	tests := []struct {
+		k        int
+		expected []string
+	}{
+		{0, []string{}},
+		{-1, []string{}},
+		{1, []string{"f"}},
+		{3, []string{"f", "ff", "e"}},
+		{7, []string{"f", "ff", "e", "d", "c", "b", "a"}},
+		{20, []string{"f", "ff", "e", "d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		got := ht.GetTopK(tt.k)
+		if len(got) != len(tt.expected) {
+			t.Errorf("GetTopK(%d): expected %v, got %v", tt.k, tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("GetTopK(%d): expected %v, got %v", tt.k, tt.expected, got)
+				break
+			}
+		}
+	}
+
+	// The prefix agrees with the fully sorted hotspots
+	counts := ht.GetHotspotsWithCounts()
+	for i, key := range ht.GetTopK(4) {
+		if counts[i].Key != key {
+			t.Errorf("expected GetTopK to agree with GetHotspotsWithCounts at %d, got %q and %q", i, key, counts[i].Key)
+		}
+	}
+}
+
+func TestNewHotspotTrackerValidation(t *testing.T) {
+	tests := []struct {
+		name            string
+		topN, numShards int
+	}{
+		{"zero topN", 0, 1},
+		{"negative topN", -1, 1},
+		{"zero shards", 1, 0},
+		{"negative shards", 1, -4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected NewHotspotTracker(%d, %d) to panic", tt.topN, tt.numShards)
+				}
+			}()
+			NewHotspotTracker(tt.topN, tt.numShards)
+		})
+	}
+
+	t.Run("nil hash", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a nil hash to panic")
+			}
+		}()
+		NewHotspotTrackerOf[int64](1, 1, nil)
+	})
+}
+
+func TestNewDefault(t *testing.T) {
+	ht := NewDefault(5)
+	expected := nextPowerOfTwo(runtime.GOMAXPROCS(0))
+	if ht.numShards != expected || len(ht.shards) != expected {
+		t.Errorf("expected %d shards, got %d", expected, ht.numShards)
+	}
+	if ht.numShards&(ht.numShards-1) != 0 || ht.numShards < runtime.GOMAXPROCS(0) {
+		t.Errorf("expected a power of two of at least GOMAXPROCS, got %d", ht.numShards)
+	}
+
+	for n, expected := range map[int]int{1: 1, 2: 2, 3: 4, 5: 8, 8: 8, 9: 16} {
+		if got := nextPowerOfTwo(n); got != expected {
+			t.Errorf("nextPowerOfTwo(%d): expected %d, got %d", n, expected, got)
+		}
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithCache(time.Hour).WithLeases(time.Hour, 10).WithDecay(time.Hour)
+	ht.Close()
+	ht.Close()
+
+	// Closing a tracker without background goroutines is a no-op
+	NewHotspotTracker(3, 2).Close()
+}
+
+func TestCloseContext(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ht := NewHotspotTracker(3, 2).WithCache(time.Millisecond).WithLeases(time.Millisecond, 10).WithDecay(time.Millisecond)
+	if n := runtime.NumGoroutine(); n < before+3 {
+		t.Fatalf("expected 3 background goroutines to start, went from %d to %d", before, n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ht.CloseContext(ctx); err != nil {
+		t.Fatalf("expected goroutines to exit, got %v", err)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected the goroutine count to return to %d, got %d", before, n)
+	}
+
+	// Closing again returns immediately
+	if err := ht.CloseContext(ctx); err != nil {
+		t.Errorf("expected a second CloseContext to succeed, got %v", err)
+	}
+}
+
+// TestRecordFastPathConcurrent hammers keys already in the heap, which are
+// incremented under the shard's read lock, and checks that no increment is
+// lost and the heaps stay ordered.
+func TestRecordFastPathConcurrent(t *testing.T) {
+	ht := NewHotspotTracker(8, 2)
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				ht.RecordRequest(keys[(g+i*i)%len(keys)])
+				if i%100 == 0 {
+					ht.GetHotspotsWithCounts()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, key := range keys {
+		freq, _ := ht.GetFrequency(key)
+		total += freq
+	}
+	if total != 8*2000 {
+		t.Errorf("expected %d recorded requests, got %d", 8*2000, total)
+	}
+
+	for si, s := range ht.shards {
+		for i, kf := range s.minHeap {
+			for _, child := range []int{2*i + 1, 2*i + 2} {
+				if child < len(s.minHeap) && s.minHeap[child].Frequency < kf.Frequency {
+					t.Errorf("shard %d: %q (%d) is above its child %q (%d)", si, kf.Key, kf.Frequency, s.minHeap[child].Key, s.minHeap[child].Frequency)
+				}
+			}
+			if int64(kf.Frequency) > s.maxFreq.Load() {
+				t.Errorf("shard %d: %q exceeds the frequency bound %d", si, kf.Key, s.maxFreq.Load())
+			}
+		}
+	}
+}
+
+// BenchmarkRecordRequestParallel records a small set of hot keys from all
+// procs at once, which mostly takes the read-locked fast path.
+func BenchmarkRecordRequestParallel(b *testing.B) {
+	ht := NewHotspotTracker(100, 4)
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			ht.RecordRequest(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func TestHotspotTies(t *testing.T) {
+	var first []string
+	for run := 0; run < 20; run++ {
+		ht := NewHotspotTracker(4, 3)
+		keys := []string{"e", "b", "d", "a", "c"}
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, key := range keys {
+			ht.RecordRequestN(key, 5)
+		}
+		ht.RecordRequestN("z", 9)
+
+		got := ht.GetHotspots()
+		if run == 0 {
+			first = got
+			// Among equally frequent keys the smaller ones rank higher
+			expected := []string{"z", "a", "b", "c"}
+			for i := range expected {
+				if i >= len(got) || got[i] != expected[i] {
+					t.Fatalf("expected %v, got %v", expected, got)
+				}
+			}
+		}
+		for i := range first {
+			if got[i] != first[i] {
+				t.Fatalf("run %d: expected the same order %v, got %v", run, first, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package htrackerprom exports a hotspot tracker's state as Prometheus metrics.
+package htrackerprom
+
+import (
+	"fmt"
+
+	"github.com/aayush993/htracker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reporting a tracker's current hotspots
+// as one gauge per key, along with its request and cache rebuild counters
+type Collector[K comparable] struct {
+	ht *htracker.HotspotTrackerOf[K]
+
+	hotspotDesc       *prometheus.Desc
+	requestsDesc      *prometheus.Desc
+	cacheRebuildsDesc *prometheus.Desc
+}
+
+// NewCollector returns a collector for ht. Metric names are prefixed with
+// namespace when it is not empty. Keys are labeled using their %v form.
+func NewCollector[K comparable](ht *htracker.HotspotTrackerOf[K], namespace string) *Collector[K] {
+	return &Collector[K]{
+		ht: ht,
+		hotspotDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "htracker", "hotspot_frequency"),
+			"Frequency of each current hotspot.",
+			[]string{"key"}, nil,
+		),
+		requestsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "htracker", "requests_total"),
+			"Requests recorded, weighted by RecordRequestN.",
+			nil, nil,
+		),
+		cacheRebuildsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "htracker", "cache_rebuilds_total"),
+			"Rebuilds of the cached aggregate of hotspots across shards.",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector[K]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hotspotDesc
+	ch <- c.requestsDesc
+	ch <- c.cacheRebuildsDesc
+}
+
+// Collect implements prometheus.Collector. It works from a copy of the
+// hotspots, so no tracker lock is held while metrics are sent.
+func (c *Collector[K]) Collect(ch chan<- prometheus.Metric) {
+	hotspots := c.ht.GetHotspotsWithCounts()
+	for _, kf := range hotspots {
+		ch <- prometheus.MustNewConstMetric(c.hotspotDesc, prometheus.GaugeValue, float64(kf.Frequency), fmt.Sprint(kf.Key))
+	}
+	m := c.ht.Metrics()
+	ch <- prometheus.MustNewConstMetric(c.requestsDesc, prometheus.CounterValue, float64(m.TotalRequests))
+	ch <- prometheus.MustNewConstMetric(c.cacheRebuildsDesc, prometheus.CounterValue, float64(m.CacheRebuilds))
+}
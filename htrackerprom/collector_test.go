@@ -0,0 +1,42 @@
+package htrackerprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aayush993/htracker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	ht := htracker.NewHotspotTracker(2, 2).WithCache(time.Hour)
+	defer ht.Close()
+	for _, key := range []string{"a", "a", "a", "b", "b", "c"} {
+		ht.RecordRequest(key)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewCollector(ht, "app")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP app_htracker_hotspot_frequency Frequency of each current hotspot.
+# TYPE app_htracker_hotspot_frequency gauge
+app_htracker_hotspot_frequency{key="a"} 3
+app_htracker_hotspot_frequency{key="b"} 2
+# HELP app_htracker_requests_total Requests recorded, weighted by RecordRequestN.
+# TYPE app_htracker_requests_total counter
+app_htracker_requests_total 6
+# HELP app_htracker_cache_rebuilds_total Rebuilds of the cached aggregate of hotspots across shards.
+# TYPE app_htracker_cache_rebuilds_total counter
+app_htracker_cache_rebuilds_total 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"app_htracker_hotspot_frequency", "app_htracker_requests_total", "app_htracker_cache_rebuilds_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
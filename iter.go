@@ -0,0 +1,23 @@
+package htracker
+
+import "iter"
+
+// Hotspots returns an iterator over the current hotspots and their
+// frequencies, most frequent first, so that callers streaming them
+// elsewhere or stopping early needn't materialize a slice. Every iteration
+// reads one aggregate, taken when it starts, like GetHotspots; the
+// aggregate is released when the iteration ends, including on break.
+func (ht *HotspotTrackerOf[K]) Hotspots() iter.Seq2[K, int] {
+	return func(yield func(K, int) bool) {
+		aggregateShard := ht.aggregate()
+		defer ht.releaseAggregate(aggregateShard)
+
+		// The aggregate's heap is in ascending rank order
+		for i := len(aggregateShard.minHeap) - 1; i >= 0; i-- {
+			kf := aggregateShard.minHeap[i]
+			if !yield(kf.Key, kf.Frequency) {
+				return
+			}
+		}
+	}
+}
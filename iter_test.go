@@ -0,0 +1,54 @@
+package htracker
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHotspotsIterator(t *testing.T) {
+	ht := NewHotspotTracker(4, 2)
+	ht.AddCounts(map[string]int{"a": 5, "b": 4, "c": 3, "d": 2, "e": 1})
+
+	var keys []string
+	var freqs []int
+	for key, freq := range ht.Hotspots() {
+		keys = append(keys, key)
+		freqs = append(freqs, freq)
+		if len(keys) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(keys, []string{"a", "b"}) || !slices.Equal(freqs, []int{5, 4}) {
+		t.Errorf("expected a=5 b=4 before the break, got %v %v", keys, freqs)
+	}
+
+	// Each iteration reads a fresh aggregate, in GetHotspots' order
+	ht.RecordRequestN("d", 10)
+	if got := slices.Collect(maps.Keys(maps.Collect(ht.Hotspots()))); len(got) != 4 {
+		t.Errorf("expected 4 hotspots, got %v", got)
+	}
+	keys = keys[:0]
+	for key := range ht.Hotspots() {
+		keys = append(keys, key)
+	}
+	if want := ht.GetHotspots(); !slices.Equal(keys, want) || keys[0] != "d" {
+		t.Errorf("expected %v starting with d, got %v", want, keys)
+	}
+}
+
+func TestHotspotsIteratorWithCache(t *testing.T) {
+	ht := NewHotspotTracker(2, 1).WithCache(time.Hour)
+	defer ht.Close()
+	ht.RecordRequest("a")
+
+	for range 2 {
+		for key, freq := range ht.Hotspots() {
+			if key != "a" || freq != 1 {
+				t.Errorf("expected a=1, got %s=%d", key, freq)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package htracker
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithCacheJitter spreads cache refreshes out in time: each refresh comes
+// a random duration within fraction of the WithCache interval before or
+// after it is due, drawn anew for every refresh. Trackers started together
+// then drift apart instead of rebuilding at the same moment. The tracker's
+// ticker checks for a due refresh jitterSteps times across that range, so
+// a refresh comes at most one such step after its drawn time. It must be
+// called before WithCache, and panics unless 0 <= fraction < 1.
+func (ht *HotspotTrackerOf[K]) WithCacheJitter(fraction float64) *HotspotTrackerOf[K] {
+	if !(fraction >= 0 && fraction < 1) {
+		panic(fmt.Sprintf("htracker: cache jitter must be in [0, 1), got %v", fraction))
+	}
+	ht.cacheJitter = fraction
+	return ht
+}
+
+// jitterSteps is how many times per jitter range the tracker's ticker
+// checks whether a jittered refresh is due
+const jitterSteps = 8
+
+// startJitteredRefresh invalidates the cache about every interval, with
+// each wait jittered by up to ht.cacheJitter of it
+func (ht *HotspotTrackerOf[K]) startJitteredRefresh(interval time.Duration) {
+	step := time.Duration(2 * ht.cacheJitter * float64(interval) / jitterSteps)
+	due := ht.clock.Now().Add(ht.jitter(interval))
+	// due is only touched from the ticker goroutine
+	ht.addTask(step, func() {
+		if now := ht.clock.Now(); !now.Before(due) {
+			due = now.Add(ht.jitter(interval))
+			ht.update.Store(true)
+		}
+	})
+}
+
+// jitter returns a random duration within ht.cacheJitter of interval
+func (ht *HotspotTrackerOf[K]) jitter(interval time.Duration) time.Duration {
+	offset := (2*ht.float64() - 1) * ht.cacheJitter * float64(interval)
+	return max(interval+time.Duration(offset), 1)
+}
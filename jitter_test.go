@@ -0,0 +1,52 @@
+package htracker
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestWithCacheJitter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(1, 1).WithClock(clock).WithRandSource(rand.NewPCG(1, 2)).
+		WithCacheJitter(0.2).WithCache(10 * time.Second)
+	defer ht.Close()
+	ht.GetHotspots()
+
+	// The jitter range is 8s to 12s, checked every 4s/jitterSteps
+	step := 4 * time.Second / jitterSteps
+	tick := clock.ticker(ht, step)
+	lo, hi := 8*time.Second, 12*time.Second+step
+	seen := make(map[time.Duration]bool)
+	last := clock.Now()
+	for refreshes := 0; refreshes < 40; {
+		tick()
+		if !ht.update.Load() {
+			continue
+		}
+		delay := clock.Now().Sub(last)
+		if delay < lo || delay >= hi {
+			t.Errorf("refresh %d: expected a delay within [%v, %v), got %v", refreshes, lo, hi, delay)
+		}
+		seen[delay] = true
+		last = clock.Now()
+		ht.GetHotspots()
+		refreshes++
+	}
+	if len(seen) < 8 {
+		t.Errorf("expected the delay to vary between refreshes, got %d distinct delays", len(seen))
+	}
+}
+
+func TestWithCacheJitterPanics(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1, 2} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected jitter %v to panic", fraction)
+				}
+			}()
+			NewHotspotTracker(1, 1).WithCacheJitter(fraction)
+		}()
+	}
+}
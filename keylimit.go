@@ -0,0 +1,110 @@
+package htracker
+
+import (
+	"cmp"
+	"slices"
+)
+
+// WithMaxKeysPerShard bounds the number of keys each shard keeps counts
+// for, so that a stream of distinct keys cannot grow memory without bound.
+// Once a shard holds more than max keys, its least frequent keys outside
+// the top N are forgotten until it is back to three quarters of max, which
+// keeps the cost of trimming low. Keys in the top N, and former hotspots
+// remembered by WithHotspotHistory, are never trimmed, and a trimmed key
+// starts over from zero if it returns. Trims are logged to
+// Events as ReasonTrimmed. It must be called before any request is
+// recorded.
+func (ht *HotspotTrackerOf[K]) WithMaxKeysPerShard(max int) *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.maxKeys = max
+	}
+	return ht
+}
+
+// WithExpectedKeys sizes each shard's key map for n distinct keys spread
+// evenly over the shards, so that warming up doesn't repeatedly grow and
+// rehash the maps. The heaps are already sized for topN. Options that keep
+// per-key state of their own, such as WithDecay and WithMinObservations,
+// get their maps sized too if they were set first. It must be called
+// before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithExpectedKeys(n int) *HotspotTrackerOf[K] {
+	perShard := max(n/ht.numShards, 0)
+	for _, s := range ht.shards {
+		s.keyFreqs = make(map[K]*KeyFreqOf[K], perShard)
+		if s.weights != nil {
+			s.weights = make(map[K]float64, perShard)
+		}
+		if s.observations != nil {
+			s.observations = make(map[K]int, perShard)
+		}
+	}
+	return ht
+}
+
+// Compact rebuilds every shard's key maps from their live entries, to
+// reclaim the memory left behind once a burst of distinct keys has been
+// removed, pruned or expired: Go maps never shrink on their own. Each shard
+// is write-locked while it is copied, so recording into it waits, and its
+// counts and hotspots are unchanged.
+func (ht *HotspotTrackerOf[K]) Compact() {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	for _, s := range ht.shards {
+		s.mu.Lock()
+		s.compactLocked()
+		s.mu.Unlock()
+	}
+}
+
+// compactLocked replaces the shard's key maps with copies sized for their
+// current contents. The KeyFreqs themselves are kept, so heap indexes stay
+// valid. The caller must hold s.mu.
+func (s *shard[K]) compactLocked() {
+	s.keyFreqs = compactMap(s.keyFreqs)
+	if s.window != nil {
+		for i, bucket := range s.window.buckets {
+			s.window.buckets[i] = compactMap(bucket)
+		}
+	}
+	if s.weights != nil {
+		s.weights = compactMap(s.weights)
+	}
+	if s.observations != nil {
+		s.observations = compactMap(s.observations)
+	}
+}
+
+// compactMap copies m into a new map sized for its length
+func compactMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// trimLocked forgets the least frequent keys outside the heap and the
+// history until the shard is back to three quarters of maxKeys, returning
+// how many it forgot.
+// The caller must hold s.mu.
+func (s *shard[K]) trimLocked() int {
+	target := s.maxKeys - s.maxKeys/4
+	cold := make([]*KeyFreqOf[K], 0, len(s.keyFreqs)-len(s.minHeap))
+	for _, kf := range s.keyFreqs {
+		if kf.Index < 0 && (s.history == nil || !s.history.remembers(kf.Key)) {
+			cold = append(cold, kf)
+		}
+	}
+	slices.SortFunc(cold, func(a, b *KeyFreqOf[K]) int {
+		return cmp.Compare(a.Frequency, b.Frequency)
+	})
+	trimmed := 0
+	for _, kf := range cold {
+		if len(s.keyFreqs) <= target {
+			break
+		}
+		s.removeLocked(kf.Key)
+		trimmed++
+	}
+	return trimmed
+}
@@ -0,0 +1,112 @@
+package htracker
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestMaxKeysPerShard(t *testing.T) {
+	const maxKeys = 100
+	ht := NewHotspotTracker(3, 2).WithMaxKeysPerShard(maxKeys)
+
+	// A few hot keys among a stream of distinct one-off keys
+	for i := 0; i < 10000; i++ {
+		ht.RecordRequest(fmt.Sprintf("noise%d", i))
+		if i%10 == 0 {
+			ht.RecordRequest("hot1")
+			ht.RecordRequest("hot2")
+			ht.RecordRequest("hot3")
+		}
+	}
+
+	for _, stat := range ht.ShardStats() {
+		if stat.Keys > maxKeys {
+			t.Errorf("shard %d: expected at most %d keys, got %d", stat.Index, maxKeys, stat.Keys)
+		}
+	}
+	for _, key := range []string{"hot1", "hot2", "hot3"} {
+		if freq, _ := ht.GetFrequency(key); freq != 1000 {
+			t.Errorf("expected %q to keep its count of 1000, got %d", key, freq)
+		}
+		if !ht.IsHotspot(key) {
+			t.Errorf("expected %q to be a hotspot", key)
+		}
+	}
+}
+
+func TestWithExpectedKeys(t *testing.T) {
+	ht := NewHotspotTracker(3, 4).WithExpectedKeys(1000)
+	for i := 0; i < 1000; i++ {
+		ht.RecordRequestN(fmt.Sprintf("key%d", i), i%7+1)
+	}
+	if got := ht.Metrics().DistinctKeys; got != 1000 {
+		t.Errorf("expected 1000 keys, got %d", got)
+	}
+	if freq, _ := ht.GetFrequency("key6"); freq != 7 {
+		t.Errorf("expected key6 to have frequency 7, got %d", freq)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithMinObservations(1)
+	for i := 0; i < 10000; i++ {
+		ht.RecordRequest(fmt.Sprintf("noise%d", i))
+	}
+	ht.RecordRequestN("hot1", 5)
+	ht.RecordRequestN("hot2", 4)
+	ht.RecordRequestN("warm", 2)
+	ht.RecordRequest("noise1")
+	ht.PruneBelow(2)
+
+	before := ht.GetAllFrequencies()
+	ht.Compact()
+	if after := ht.GetAllFrequencies(); !maps.Equal(before, after) {
+		t.Errorf("expected Compact to keep %v, got %v", before, after)
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"hot1", "hot2", "noise1"}) {
+		t.Errorf("expected [hot1 hot2 noise1], got %v", got)
+	}
+
+	// The heap still points at the live KeyFreqs
+	ht.RecordRequestN("warm", 10)
+	if got := ht.GetHotspots(); got[0] != "warm" {
+		t.Errorf("expected warm to lead after compaction, got %v", got)
+	}
+	for _, s := range ht.shards {
+		for _, kf := range s.minHeap {
+			if err := s.checkIndex(kf); err != nil {
+				t.Error(err)
+			}
+			if s.keyFreqs[kf.Key] != kf {
+				t.Errorf("key %q: heap entry is not the live KeyFreq", kf.Key)
+			}
+		}
+	}
+}
+
+// BenchmarkWarmUp records 10000 distinct keys into a fresh tracker, with
+// and without sizing the shard maps up front
+func BenchmarkWarmUp(b *testing.B) {
+	const distinct = 10000
+	keys := make([]string, distinct)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	for _, hint := range []bool{false, true} {
+		b.Run(fmt.Sprintf("hint=%v", hint), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ht := NewHotspotTracker(100, 8)
+				if hint {
+					ht.WithExpectedKeys(distinct)
+				}
+				for _, key := range keys {
+					ht.RecordRequest(key)
+				}
+			}
+		})
+	}
+}
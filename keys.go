@@ -0,0 +1,168 @@
+package htracker
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// keyTemplate rewrites keys matching pattern using regexp replacement syntax
+type keyTemplate struct {
+	pattern  *regexp.Regexp
+	template string
+}
+
+// WithKeyTemplate rewrites every key matching pattern with template before it
+// is stored or queried, so that templated paths such as /users/123 and
+// /users/456 are grouped under a single key like /users/{id}.
+// Templates are applied in the order they were added. The template supports
+// the same $1 style expansion as regexp.ReplaceAllString. Templates only
+// make sense for keys whose underlying type is string, so it panics for any
+// other key type.
+func (ht *HotspotTrackerOf[K]) WithKeyTemplate(pattern *regexp.Regexp, template string) *HotspotTrackerOf[K] {
+	if !isStringKey[K]() {
+		panic(fmt.Sprintf("htracker: WithKeyTemplate requires string keys, got %v", reflect.TypeFor[K]()))
+	}
+	ht.keyTemplates = append(ht.keyTemplates, keyTemplate{pattern: pattern, template: template})
+	return ht
+}
+
+// WithKeyNormalizer maps every key through fn before it is stored or
+// queried, so that keys fn considers equivalent, such as differently cased
+// URLs, are counted as one. GetHotspots and the other reads return the
+// normalized form. fn runs before any key template.
+func (ht *HotspotTrackerOf[K]) WithKeyNormalizer(fn func(K) K) *HotspotTrackerOf[K] {
+	ht.keyNormalizer = fn
+	return ht
+}
+
+// normalizeKey applies the configured key rewrites to key
+func (ht *HotspotTrackerOf[K]) normalizeKey(key K) K {
+	if ht.keyNormalizer != nil {
+		key = ht.keyNormalizer(key)
+	}
+	if len(ht.keyTemplates) == 0 {
+		return key
+	}
+	s := keyString(key)
+	for _, kt := range ht.keyTemplates {
+		s = kt.pattern.ReplaceAllString(s, kt.template)
+	}
+	return stringKey[K](s)
+}
+
+// isStringKey reports whether K's underlying type is string
+func isStringKey[K comparable]() bool {
+	return reflect.TypeFor[K]().Kind() == reflect.String
+}
+
+// keyString returns a key whose underlying type is string as a string
+func keyString[K comparable](key K) string {
+	if s, ok := any(key).(string); ok {
+		return s
+	}
+	return reflect.ValueOf(key).String()
+}
+
+// stringKey converts s to K, whose underlying type must be string
+func stringKey[K comparable](s string) K {
+	if key, ok := any(s).(K); ok {
+		return key
+	}
+	var key K
+	reflect.ValueOf(&key).Elem().SetString(s)
+	return key
+}
+
+// compareKeys orders keys to break frequency ties deterministically. Keys
+// compare by their underlying kind: strings and numbers naturally, and
+// arrays and structs element by element.
+func compareKeys[K comparable](a, b K) int {
+	// Switching on pointers matches K itself, never the dynamic type of an
+	// interface K, so b is always of the same type
+	switch pa := any(&a).(type) {
+	case *string:
+		return strings.Compare(*pa, *any(&b).(*string))
+	case *int:
+		return cmp.Compare(*pa, *any(&b).(*int))
+	case *int32:
+		return cmp.Compare(*pa, *any(&b).(*int32))
+	case *int64:
+		return cmp.Compare(*pa, *any(&b).(*int64))
+	case *uint:
+		return cmp.Compare(*pa, *any(&b).(*uint))
+	case *uint32:
+		return cmp.Compare(*pa, *any(&b).(*uint32))
+	case *uint64:
+		return cmp.Compare(*pa, *any(&b).(*uint64))
+	}
+	return compareReflect(a, b)
+}
+
+// compareReflect is compareKeys for any other K. It is kept apart so that
+// compareKeys' arguments don't escape.
+func compareReflect[K comparable](a, b K) int {
+	return compareValues(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+}
+
+// compareValues orders two comparable values by their underlying kind.
+// Values held in interfaces compare by their dynamic values, ordered by
+// type first when the types differ, and pointers and channels compare by
+// address, which is stable for as long as the process runs.
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() {
+		// Nil interfaces come first
+		return cmp.Compare(boolInt(a.IsValid()), boolInt(b.IsValid()))
+	}
+	if a.Type() != b.Type() {
+		return strings.Compare(a.Type().String(), b.Type().String())
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		if c := cmp.Compare(real(a.Complex()), real(b.Complex())); c != 0 {
+			return c
+		}
+		return cmp.Compare(imag(a.Complex()), imag(b.Complex()))
+	case reflect.Bool:
+		return cmp.Compare(boolInt(a.Bool()), boolInt(b.Bool()))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return cmp.Compare(a.Pointer(), b.Pointer())
+	case reflect.Array:
+		for i := range a.Len() {
+			if c := compareValues(a.Index(i), b.Index(i)); c != 0 {
+				return c
+			}
+		}
+	case reflect.Struct:
+		for i := range a.NumField() {
+			if c := compareValues(a.Field(i), b.Field(i)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package htracker
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestKeyTemplate(t *testing.T) {
+	ht := NewHotspotTracker(2, 2).
+		WithKeyTemplate(regexp.MustCompile(`^/users/\d+`), "/users/{id}").
+		WithKeyTemplate(regexp.MustCompile(`^/orders/\d+$`), "/orders/{id}")
+
+	keys := []string{
+		"/users/1", "/users/2", "/users/3/profile", "/users/42",
+		"/orders/7", "/orders/8",
+		"/health",
+	}
+	for _, key := range keys {
+		ht.RecordRequest(key)
+	}
+
+	expectedHotspots := map[string]bool{"/users/{id}": true, "/orders/{id}": true}
+	hotspots := ht.GetHotspots()
+	if len(hotspots) != 2 {
+		t.Fatalf("expected 2 hotspots, got %v", hotspots)
+	}
+	for _, key := range hotspots {
+		if !expectedHotspots[key] {
+			t.Errorf("unexpected hotspot: %s", key)
+		}
+	}
+
+	if !ht.IsHotspot("/users/999") {
+		t.Error("expected '/users/999' to resolve to a hotspot")
+	}
+	if ht.IsHotspot("/health") {
+		t.Error("did not expect '/health' to be a hotspot")
+	}
+}
+
+func TestWithKeyTemplateRequiresStringKeys(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected WithKeyTemplate to panic for int64 keys")
+		}
+	}()
+	NewHotspotTrackerOf(1, 1, func(id int64) uint32 { return uint32(id) }).
+		WithKeyTemplate(regexp.MustCompile(`\d+`), "{id}")
+}
+
+// routeKey is a named string key type
+type routeKey string
+
+func TestKeyTemplateNamedString(t *testing.T) {
+	ht := NewHotspotTrackerOf(2, 2, func(k routeKey) uint32 { return uint32(len(k)) }).
+		WithKeyTemplate(regexp.MustCompile(`^/users/\d+`), "/users/{id}")
+	ht.RecordRequest("/users/1")
+	ht.RecordRequest("/users/2")
+
+	if freq, _ := ht.GetFrequency("/users/{id}"); freq != 2 {
+		t.Errorf("expected the templated key to count 2, got %d", freq)
+	}
+}
+
+func TestCompareKeys(t *testing.T) {
+	type point struct{ X, Y int }
+	type level int
+
+	for _, tt := range []struct {
+		name     string
+		got      int
+		expected int
+	}{
+		{"string", compareKeys("a", "b"), -1},
+		{"named string", compareKeys(routeKey("b"), routeKey("a")), 1},
+		{"named int", compareKeys(level(2), level(10)), -1},
+		{"int8", compareKeys(int8(-3), int8(3)), -1},
+		{"float", compareKeys(2.5, 2.25), 1},
+		{"bool", compareKeys(false, true), -1},
+		{"array", compareKeys([2]int{1, 10}, [2]int{1, 9}), 1},
+		{"struct", compareKeys(point{1, 2}, point{1, 2}), 0},
+		{"struct fields in order", compareKeys(point{0, 9}, point{1, 0}), -1},
+		{"interface", compareKeys[any](3, 12), -1},
+		{"interface types", compareKeys[any](1, "1"), -1},
+		{"nil interface", compareKeys[any](nil, 0), -1},
+	} {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, tt.got)
+		}
+	}
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	ht := NewHotspotTracker(2, 4).WithKeyNormalizer(func(key string) string {
+		return strings.TrimSuffix(strings.ToLower(key), "/")
+	})
+
+	for _, key := range []string{"A", "a", "a/", "B"} {
+		ht.RecordRequest(key)
+	}
+
+	if hotspots := ht.GetHotspots(); len(hotspots) != 2 || hotspots[0] != "a" || hotspots[1] != "b" {
+		t.Errorf("expected ['a' 'b'], got %v", hotspots)
+	}
+	if freq, ok := ht.GetFrequency("A/"); !ok || freq != 3 {
+		t.Errorf("expected 'A/' to read the merged count 3, got (%d, %v)", freq, ok)
+	}
+	if !ht.IsHotspot("A") {
+		t.Error("expected 'A' to be a hotspot through normalization")
+	}
+	if !ht.RemoveKey("b/") || ht.IsHotspot("b") {
+		t.Error("expected removing 'b/' to remove 'b'")
+	}
+}
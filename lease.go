@@ -0,0 +1,127 @@
+package htracker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTooManyLeases is returned by RecordLease when the lease wheel is full
+var ErrTooManyLeases = errors.New("htracker: too many pending leases")
+
+// ErrLeasesDisabled is returned by RecordLease when WithLeases was not called
+var ErrLeasesDisabled = errors.New("htracker: leases are not enabled")
+
+// leaseWheelSlots is the number of slots in the lease timer wheel. Leases
+// longer than one revolution wait out the extra rounds in their slot.
+const leaseWheelSlots = 256
+
+// WithLeases enables RecordLease. Lease expiries are tracked in a single
+// timer wheel that advances every resolution from the tracker's ticker,
+// which Close stops, so a lease expires within one resolution of its ttl.
+// At most maxLeases leases may be pending at once.
+func (ht *HotspotTrackerOf[K]) WithLeases(resolution time.Duration, maxLeases int) *HotspotTrackerOf[K] {
+	ht.leases = newLeaseWheel[K](resolution, maxLeases)
+	ht.addTask(resolution, ht.expireLeases)
+	return ht
+}
+
+// RecordLease records a request for key that is automatically taken back
+// after ttl. This models currently-in-use resources, where a key is hot for
+// as long as it has outstanding leases, rather than cumulative requests.
+func (ht *HotspotTrackerOf[K]) RecordLease(key K, ttl time.Duration) error {
+	if ht.leases == nil {
+		return ErrLeasesDisabled
+	}
+	if !ht.leases.reserve() {
+		return ErrTooManyLeases
+	}
+	// Record before scheduling so the expiry can never overtake the increment
+	key = ht.normalizeKey(key)
+	ht.record(key, 1)
+	ht.leases.schedule(key, ttl)
+	return nil
+}
+
+// expireLeases advances the lease wheel by one tick and takes back every
+// expired lease
+func (ht *HotspotTrackerOf[K]) expireLeases() {
+	expired := ht.leases.advance()
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	for _, key := range expired {
+		ht.shards[ht.shardIndex(key)].decrement(key)
+	}
+}
+
+// lease is a pending decrement for key, due once its slot has been reached
+// rounds more times
+type lease[K comparable] struct {
+	key    K
+	rounds int
+}
+
+// leaseWheel is a hashed timer wheel of pending leases
+type leaseWheel[K comparable] struct {
+	mu         sync.Mutex
+	resolution time.Duration
+	slots      [][]lease[K]
+	current    int
+	pending    int
+	max        int
+}
+
+func newLeaseWheel[K comparable](resolution time.Duration, max int) *leaseWheel[K] {
+	return &leaseWheel[K]{
+		resolution: resolution,
+		slots:      make([][]lease[K], leaseWheelSlots),
+		max:        max,
+	}
+}
+
+// reserve claims room for one lease, reporting false if the wheel is full
+func (w *leaseWheel[K]) reserve() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending >= w.max {
+		return false
+	}
+	w.pending++
+	return true
+}
+
+// schedule adds a previously reserved lease for key, expiring after ttl
+func (w *leaseWheel[K]) schedule(key K, ttl time.Duration) {
+	ticks := int((ttl + w.resolution - 1) / w.resolution)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	slot := (w.current + ticks) % len(w.slots)
+	w.slots[slot] = append(w.slots[slot], lease[K]{key: key, rounds: (ticks - 1) / len(w.slots)})
+}
+
+// advance moves the wheel forward one tick and returns the expired keys
+func (w *leaseWheel[K]) advance() []K {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.current = (w.current + 1) % len(w.slots)
+	var expired []K
+	kept := w.slots[w.current][:0]
+	for _, l := range w.slots[w.current] {
+		if l.rounds > 0 {
+			l.rounds--
+			kept = append(kept, l)
+			continue
+		}
+		expired = append(expired, l.key)
+	}
+	w.slots[w.current] = kept
+	w.pending -= len(expired)
+	return expired
+}
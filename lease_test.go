@@ -0,0 +1,142 @@
+package htracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordLease(t *testing.T) {
+	// A long resolution keeps the background ticker out of the way so the
+	// test can advance the wheel deterministically
+	ht := NewHotspotTracker(3, 2).WithLeases(time.Hour, 10)
+	defer ht.Close()
+
+	if err := ht.RecordLease("db", 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.RecordLease("db", 3*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.RecordLease("cache", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ht.RecordRequest("db")
+
+	assertFrequency := func(key string, expected int) {
+		t.Helper()
+		freq, _ := ht.GetFrequency(key)
+		if freq != expected {
+			t.Errorf("expected %q to have frequency %d, got %d", key, expected, freq)
+		}
+	}
+	assertFrequency("db", 3)
+	assertFrequency("cache", 1)
+
+	ht.expireLeases()
+	assertFrequency("db", 3)
+	if ht.IsHotspot("cache") {
+		t.Error("expected 'cache' to drop out once its only lease expired")
+	}
+
+	ht.expireLeases()
+	assertFrequency("db", 2)
+	ht.expireLeases()
+	// The plain request is never taken back
+	assertFrequency("db", 1)
+}
+
+func TestRecordLeasePromotes(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithLeases(time.Hour, 10)
+	defer ht.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := ht.RecordLease("a", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ht.RecordRequestN("b", 2)
+
+	// "a" drops from 3 to 0 and "b" must replace it
+	ht.expireLeases()
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "b" {
+		t.Errorf("expected 'b' to replace the expired 'a', got %v", hotspots)
+	}
+
+	// A decremented hotspot that falls below another key swaps with it
+	ht.RecordRequestN("c", 1)
+	if err := ht.RecordLease("c", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ht.RecordRequestN("c", 1)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "c" {
+		t.Fatalf("expected 'c' to lead with 3, got %v", hotspots)
+	}
+	ht.expireLeases()
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "b" {
+		t.Errorf("expected 'b' to outrank the decremented 'c' by key, got %v", hotspots)
+	}
+}
+
+func TestRecordLeaseLongTTL(t *testing.T) {
+	ht := NewHotspotTracker(3, 1).WithLeases(time.Hour, 10)
+	defer ht.Close()
+
+	ticks := leaseWheelSlots + 2
+	if err := ht.RecordLease("a", time.Duration(ticks)*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < ticks; i++ {
+		ht.expireLeases()
+	}
+	if !ht.IsHotspot("a") {
+		t.Fatal("expected lease to outlive a full revolution of the wheel")
+	}
+	ht.expireLeases()
+	if ht.IsHotspot("a") {
+		t.Error("expected lease to expire after its ttl")
+	}
+}
+
+func TestRecordLeaseBounded(t *testing.T) {
+	ht := NewHotspotTracker(3, 1)
+	if err := ht.RecordLease("a", time.Second); !errors.Is(err, ErrLeasesDisabled) {
+		t.Errorf("expected ErrLeasesDisabled, got %v", err)
+	}
+
+	ht.WithLeases(time.Hour, 2)
+	defer ht.Close()
+	for i := 0; i < 2; i++ {
+		if err := ht.RecordLease("a", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ht.RecordLease("a", time.Hour); !errors.Is(err, ErrTooManyLeases) {
+		t.Errorf("expected ErrTooManyLeases, got %v", err)
+	}
+	if freq, _ := ht.GetFrequency("a"); freq != 2 {
+		t.Errorf("expected rejected lease not to be recorded, got frequency %d", freq)
+	}
+
+	// Expired leases free up room
+	ht.expireLeases()
+	if err := ht.RecordLease("a", time.Hour); err != nil {
+		t.Errorf("expected room for a new lease, got %v", err)
+	}
+}
+
+func TestRecordLeaseExpiresInBackground(t *testing.T) {
+	ht := NewHotspotTracker(3, 1).WithLeases(time.Millisecond, 10)
+	defer ht.Close()
+
+	if err := ht.RecordLease("a", 2*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for ht.IsHotspot("a") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected lease to expire in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
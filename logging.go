@@ -0,0 +1,39 @@
+package htracker
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger is the logger of trackers without WithLogger
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that is never enabled
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// WithLogger makes the tracker log significant events to logger at Debug
+// level: cache rebuilds, evictions from a shard's top N and resizes. Events
+// are logged after every lock is released. A nil logger disables logging,
+// which is the default.
+func (ht *HotspotTrackerOf[K]) WithLogger(logger *slog.Logger) *HotspotTrackerOf[K] {
+	if logger == nil {
+		logger = discardLogger
+	}
+	ht.logger = logger
+	return ht
+}
+
+// logDebug logs msg with attrs at Debug level. Callers on hot paths should
+// check debugEnabled first, to skip building attrs nobody will read.
+func (ht *HotspotTrackerOf[K]) logDebug(msg string, attrs ...slog.Attr) {
+	ht.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+func (ht *HotspotTrackerOf[K]) debugEnabled() bool {
+	return ht.logger.Enabled(context.Background(), slog.LevelDebug)
+}
@@ -0,0 +1,105 @@
+package htracker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler is a slog.Handler keeping every record it handles
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// find returns the attributes of every record logged with msg
+func (h *recordingHandler) find(msg string) []map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var found []map[string]any
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		attrs := make(map[string]any)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.Any()
+			return true
+		})
+		found = append(found, attrs)
+	}
+	return found
+}
+
+func TestLoggerCacheRebuilds(t *testing.T) {
+	handler := &recordingHandler{}
+	ht := New(TopN(2), NumShards(1), Cache(time.Millisecond), Logger(slog.New(handler)))
+	defer ht.Close()
+
+	ht.RecordRequest("a")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(handler.find("htracker: cache rebuilt")) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least 2 cache rebuilds to be logged, got %d", len(handler.find("htracker: cache rebuilt")))
+		}
+		ht.GetHotspots()
+		time.Sleep(time.Millisecond)
+	}
+
+	rebuilt := handler.find("htracker: cache rebuilt")[0]
+	if rebuilt["hotspots"] != int64(1) || rebuilt["rebuilds"] != int64(1) {
+		t.Errorf("unexpected attributes for the first rebuild: %v", rebuilt)
+	}
+	for _, r := range handler.records {
+		if r.Level != slog.LevelDebug {
+			t.Errorf("expected %q to be logged at Debug level, got %v", r.Message, r.Level)
+		}
+	}
+}
+
+func TestLoggerEvictionsAndResize(t *testing.T) {
+	handler := &recordingHandler{}
+	ht := NewHotspotTracker(1, 1).WithLogger(slog.New(handler))
+
+	ht.RecordRequest("a")
+	ht.RecordRequestN("b", 2)
+	evicted := handler.find("htracker: hotspot evicted")
+	if len(evicted) != 1 {
+		t.Fatalf("expected 1 eviction to be logged, got %v", evicted)
+	}
+	if evicted[0]["shard"] != int64(0) || evicted[0]["key"] != "a" || evicted[0]["frequency"] != int64(1) {
+		t.Errorf("unexpected eviction attributes: %v", evicted[0])
+	}
+
+	if err := ht.Resize(4); err != nil {
+		t.Fatal(err)
+	}
+	resized := handler.find("htracker: shards resized")
+	if len(resized) != 1 || resized[0]["from"] != int64(1) || resized[0]["to"] != int64(4) || resized[0]["keys"] != int64(2) {
+		t.Errorf("unexpected resize records: %v", resized)
+	}
+}
+
+func TestLoggerDefaultsToDiscard(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithLogger(nil)
+	ht.RecordRequest("a")
+	ht.RecordRequest("b")
+	ht.RecordRequest("b")
+	if ht.debugEnabled() {
+		t.Error("expected logging to be disabled without a logger")
+	}
+}
@@ -0,0 +1,33 @@
+package htracker
+
+import "errors"
+
+// ErrMergeSelf is returned by Merge when a tracker is merged into itself
+var ErrMergeSelf = errors.New("htracker: cannot merge a tracker into itself")
+
+// Merge adds the count of every key in other to the receiver, as if they
+// had been added with AddCounts, so the counts are not sampled again under
+// WithSampleRate. Keys are rehashed into the receiver's shards, so the two
+// trackers may differ in shard count and topN. other's counts are copied,
+// one shard lock at a time, and every lock of other is released before
+// recording into the receiver, so two trackers merging into each other
+// concurrently cannot deadlock.
+func (ht *HotspotTrackerOf[K]) Merge(other *HotspotTrackerOf[K]) error {
+	if other == ht {
+		return ErrMergeSelf
+	}
+
+	counts := make(map[K]int)
+	other.resizeMu.RLock()
+	for _, s := range other.shards {
+		s.mu.RLock()
+		for key, kf := range s.keyFreqs {
+			counts[key] += loadFrequency(&kf.Frequency)
+		}
+		s.mu.RUnlock()
+	}
+	other.resizeMu.RUnlock()
+
+	ht.AddCounts(counts)
+	return nil
+}
@@ -0,0 +1,58 @@
+package htracker
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := NewHotspotTracker(3, 4)
+	a.RecordRequestN("x", 5)
+	a.RecordRequestN("y", 2)
+	a.RecordRequestN("z", 1)
+
+	b := NewHotspotTracker(2, 3)
+	b.RecordRequestN("x", 4)
+	b.RecordRequestN("w", 7)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{"x": 9, "y": 2, "z": 1, "w": 7}
+	for key, freq := range expected {
+		if got, _ := a.GetFrequency(key); got != freq {
+			t.Errorf("expected %q to have merged frequency %d, got %d", key, freq, got)
+		}
+	}
+	assertKeyFreqs(t, a.GetHotspotsWithCounts(), []KeyFreq{{Key: "x", Frequency: 9}, {Key: "w", Frequency: 7}, {Key: "y", Frequency: 2}})
+	if a.TotalRequests() != 19 {
+		t.Errorf("expected 19 total requests, got %d", a.TotalRequests())
+	}
+
+	// The source is left untouched
+	if got, _ := b.GetFrequency("x"); got != 4 {
+		t.Errorf("expected the source to keep 'x' at 4, got %d", got)
+	}
+
+	if err := a.Merge(a); !errors.Is(err, ErrMergeSelf) {
+		t.Errorf("expected ErrMergeSelf, got %v", err)
+	}
+}
+
+func TestMergeIntoSampled(t *testing.T) {
+	// The source's counts are already totals, so they must not be sampled again
+	a := NewHotspotTracker(2, 2).WithSampleRate(0.1).WithRandSource(rand.NewPCG(1, 2))
+	b := NewHotspotTracker(2, 2)
+	b.RecordRequestN("a", 7)
+	b.RecordRequestN("b", 3)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	assertKeyFreqs(t, a.GetHotspotsWithCounts(), []KeyFreq{{Key: "a", Frequency: 7}, {Key: "b", Frequency: 3}})
+	if a.TotalRequests() != 10 {
+		t.Errorf("expected 10 total requests, got %d", a.TotalRequests())
+	}
+}
@@ -0,0 +1,33 @@
+package htracker
+
+// TrackerMetrics is a snapshot of the tracker's internal counters
+type TrackerMetrics struct {
+	// TotalRequests is the number of requests recorded, as TotalRequests
+	TotalRequests int64
+	// CacheRebuilds is the number of times the cache was rebuilt, always 0
+	// without WithCache
+	CacheRebuilds int64
+	// Evictions is the number of keys pushed out of a shard's top N by a
+	// more frequent key
+	Evictions int64
+	// DistinctKeys is the number of keys the shards currently hold counts
+	// for; with WithSketch only the keys in each shard's top N
+	DistinctKeys int64
+}
+
+// Metrics returns the tracker's counters. Each is read with an atomic load
+// and no lock is taken, so it is cheap enough to call on every log line,
+// but the counters are not read at exactly the same instant.
+func (ht *HotspotTrackerOf[K]) Metrics() TrackerMetrics {
+	m := TrackerMetrics{
+		TotalRequests: ht.totalRequests.Load(),
+		CacheRebuilds: ht.cacheRebuilds.Load(),
+		Evictions:     ht.evictions.Load(),
+	}
+	ht.resizeMu.RLock()
+	for _, s := range ht.shards {
+		m.DistinctKeys += s.keyCount.Load()
+	}
+	ht.resizeMu.RUnlock()
+	return m
+}
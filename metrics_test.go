@@ -0,0 +1,44 @@
+package htracker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	ht.RecordRequest("a")
+	ht.RecordRequestN("b", 2)
+	ht.RecordRequest("c")
+
+	got := ht.Metrics()
+	want := TrackerMetrics{TotalRequests: 4, Evictions: 1, DistinctKeys: 3}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	ht.RemoveKey("c")
+	if got := ht.Metrics().DistinctKeys; got != 2 {
+		t.Errorf("expected 2 distinct keys after RemoveKey, got %d", got)
+	}
+}
+
+func TestMetricsCacheRebuilds(t *testing.T) {
+	ht := NewHotspotTracker(2, 2).WithCache(time.Millisecond)
+	defer ht.Close()
+
+	ht.RecordRequest("a")
+	ht.GetHotspots()
+	if got := ht.Metrics().CacheRebuilds; got != 1 {
+		t.Fatalf("expected 1 cache rebuild after the first read, got %d", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for ht.Metrics().CacheRebuilds < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the cache to be rebuilt every interval, got %d rebuilds", ht.Metrics().CacheRebuilds)
+		}
+		time.Sleep(time.Millisecond)
+		ht.GetHotspots()
+	}
+}
@@ -0,0 +1,149 @@
+package htracker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NamespacedOf tracks hotspots of keys of type K separately for each of
+// many namespaces, such as tenants of a shared service. A namespace's
+// tracker is created on its first request and dropped once it has been
+// idle for a while.
+type NamespacedOf[K comparable] struct {
+	newTracker func() *HotspotTrackerOf[K]
+	idleAfter  time.Duration
+	clock      Clock
+
+	mu         sync.RWMutex
+	namespaces map[string]*namespace[K]
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	workers   sync.WaitGroup
+}
+
+// Namespaced is a NamespacedOf string keys
+type Namespaced = NamespacedOf[string]
+
+// namespace is a namespace's tracker and when it was last recorded into,
+// in Unix nanoseconds
+type namespace[K comparable] struct {
+	tracker  *HotspotTrackerOf[K]
+	lastUsed atomic.Int64
+}
+
+// NewNamespaced returns a Namespaced creating each namespace's tracker
+// with newTracker, so that every namespace shares its configuration. A
+// namespace not recorded into for idleAfter is closed and forgotten,
+// checked every idleAfter/2 from a goroutine which Close stops; with
+// idleAfter <= 0 namespaces are kept until Close.
+func NewNamespaced(newTracker func() *HotspotTracker, idleAfter time.Duration) *Namespaced {
+	return NewNamespacedOf(newTracker, idleAfter)
+}
+
+// NewNamespacedOf is NewNamespaced for trackers of keys of type K
+func NewNamespacedOf[K comparable](newTracker func() *HotspotTrackerOf[K], idleAfter time.Duration) *NamespacedOf[K] {
+	n := &NamespacedOf[K]{
+		newTracker: newTracker,
+		idleAfter:  idleAfter,
+		clock:      realClock{},
+		namespaces: make(map[string]*namespace[K]),
+		stop:       make(chan struct{}),
+	}
+	if idleAfter > 0 {
+		n.startSweeper()
+	}
+	return n
+}
+
+// RecordRequest records a request for key in namespace ns, creating the
+// namespace's tracker if needed
+func (n *NamespacedOf[K]) RecordRequest(ns string, key K) {
+	space := n.get(ns)
+	space.lastUsed.Store(n.clock.Now().UnixNano())
+	space.tracker.RecordRequest(key)
+}
+
+// GetHotspots returns the hotspots of namespace ns, most frequent first,
+// or nil if it has no tracker
+func (n *NamespacedOf[K]) GetHotspots(ns string) []K {
+	n.mu.RLock()
+	space, exists := n.namespaces[ns]
+	n.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	return space.tracker.GetHotspots()
+}
+
+// Namespaces returns the number of namespaces currently tracked
+func (n *NamespacedOf[K]) Namespaces() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.namespaces)
+}
+
+// Close stops the idle sweeper and closes every namespace's tracker
+func (n *NamespacedOf[K]) Close() {
+	n.closeOnce.Do(func() {
+		close(n.stop)
+		n.workers.Wait()
+
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		for _, space := range n.namespaces {
+			space.tracker.Close()
+		}
+		clear(n.namespaces)
+	})
+}
+
+// get returns namespace ns, creating it if needed
+func (n *NamespacedOf[K]) get(ns string) *namespace[K] {
+	n.mu.RLock()
+	space, exists := n.namespaces[ns]
+	n.mu.RUnlock()
+	if exists {
+		return space
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if space, exists = n.namespaces[ns]; !exists {
+		space = &namespace[K]{tracker: n.newTracker()}
+		n.namespaces[ns] = space
+	}
+	return space
+}
+
+func (n *NamespacedOf[K]) startSweeper() {
+	ticker := n.clock.NewTicker(max(n.idleAfter/2, 1))
+	n.workers.Add(1)
+	go func() {
+		defer n.workers.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				n.sweep(n.clock.Now())
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+}
+
+// sweep closes and forgets every namespace idle for idleAfter as of now.
+// A request racing with the sweep may land in a tracker just dropped.
+func (n *NamespacedOf[K]) sweep(now time.Time) {
+	cutoff := now.Add(-n.idleAfter).UnixNano()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ns, space := range n.namespaces {
+		if space.lastUsed.Load() <= cutoff {
+			space.tracker.Close()
+			delete(n.namespaces, ns)
+		}
+	}
+}
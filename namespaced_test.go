@@ -0,0 +1,95 @@
+package htracker
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNamespaced(t *testing.T) {
+	n := NewNamespaced(func() *HotspotTracker { return NewHotspotTracker(2, 2) }, 0)
+	defer n.Close()
+
+	for i := 0; i < 3; i++ {
+		n.RecordRequest("shop", "cart")
+		n.RecordRequest("blog", "post")
+	}
+	n.RecordRequest("shop", "checkout")
+	n.RecordRequest("blog", "cart")
+
+	if got := n.GetHotspots("shop"); !slices.Equal(got, []string{"cart", "checkout"}) {
+		t.Errorf("expected shop hotspots [cart checkout], got %v", got)
+	}
+	if got := n.GetHotspots("blog"); !slices.Equal(got, []string{"post", "cart"}) {
+		t.Errorf("expected blog hotspots [post cart], got %v", got)
+	}
+	if got := n.GetHotspots("unknown"); got != nil {
+		t.Errorf("expected no hotspots for an unknown namespace, got %v", got)
+	}
+	if n.Namespaces() != 2 {
+		t.Errorf("expected GetHotspots not to create namespaces, got %d", n.Namespaces())
+	}
+}
+
+func TestNamespacedOf(t *testing.T) {
+	n := NewNamespacedOf(func() *HotspotTrackerOf[int] {
+		return NewHotspotTrackerOf(1, 1, func(k int) uint32 { return uint32(k) })
+	}, 0)
+	defer n.Close()
+
+	n.RecordRequest("shop", 1)
+	n.RecordRequest("shop", 2)
+	n.RecordRequest("shop", 2)
+	if got := n.GetHotspots("shop"); !slices.Equal(got, []int{2}) {
+		t.Errorf("expected [2], got %v", got)
+	}
+}
+
+func TestNamespacedConcurrent(t *testing.T) {
+	n := NewNamespaced(func() *HotspotTracker { return NewHotspotTracker(5, 2) }, 0)
+	defer n.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				n.RecordRequest(fmt.Sprintf("ns%d", i%4), fmt.Sprintf("key%d", w))
+				n.GetHotspots(fmt.Sprintf("ns%d", (i+1)%4))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := 0; i < 4; i++ {
+		tracker := n.namespaces[fmt.Sprintf("ns%d", i)].tracker
+		if got := tracker.TotalRequests(); got != 8*125 {
+			t.Errorf("ns%d: expected %d requests, got %d", i, 8*125, got)
+		}
+	}
+}
+
+func TestNamespacedIdleSweep(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	n := NewNamespaced(func() *HotspotTracker { return NewHotspotTracker(2, 1) }, time.Minute)
+	n.clock = clock
+	defer n.Close()
+
+	n.RecordRequest("old", "a")
+	clock.Advance(50 * time.Second)
+	n.RecordRequest("new", "b")
+
+	n.sweep(clock.Now().Add(20 * time.Second))
+	if n.GetHotspots("old") != nil || n.GetHotspots("new") == nil {
+		t.Errorf("expected only the idle namespace to be dropped, %d left", n.Namespaces())
+	}
+
+	// A dropped namespace starts over
+	n.RecordRequest("old", "c")
+	if got := n.GetHotspots("old"); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("expected a fresh tracker for old, got %v", got)
+	}
+}
@@ -0,0 +1,25 @@
+package htracker
+
+// WithMinObservations only lets a key become a hotspot once it has been
+// recorded at least n separate times, however heavy each request was, so
+// that a single burst recorded with RecordRequestN cannot make a key hot on
+// its own. Observations are counted apart from the weighted frequency, and
+// keys short of n stay tracked but are kept out of their shard's top N, so
+// they don't take a slot from an observed key. AddCounts observes each key
+// once. Approximate trackers built with WithSketch do not count
+// observations, so combining it with WithSketch panics. It must be called
+// before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithMinObservations(n int) *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.observations = make(map[K]int)
+		s.minObservations = n
+	}
+	ht.checkSketch()
+	return ht
+}
+
+// eligible reports whether key has been recorded often enough to enter the
+// heap. The caller must hold at least s.mu's read lock.
+func (s *shard[K]) eligible(key K) bool {
+	return s.minObservations <= 0 || s.observations[key] >= s.minObservations
+}
@@ -0,0 +1,102 @@
+package htracker
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestWithMinObservations(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithMinObservations(3)
+
+	// A single heavy burst outweighs everything else but is observed once
+	ht.RecordRequestN("burst", 100)
+	for i := 0; i < 4; i++ {
+		ht.RecordRequest("steady")
+	}
+	ht.RecordRequest("rare")
+	ht.RecordRequest("rare")
+
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"steady"}) {
+		t.Errorf("expected only [steady] to be hot, got %v", got)
+	}
+	if ht.IsHotspot("burst") {
+		t.Error("expected the single burst not to be a hotspot")
+	}
+	if freq, _ := ht.GetFrequency("burst"); freq != 100 {
+		t.Errorf("expected the burst to stay tracked with frequency 100, got %d", freq)
+	}
+
+	// Once observed often enough, keys rank by weighted frequency again
+	ht.RecordRequest("burst")
+	ht.RecordRequest("burst")
+	ht.RecordRequest("rare")
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"burst", "steady", "rare"}) {
+		t.Errorf("expected [burst steady rare], got %v", got)
+	}
+
+	// A removed key starts over
+	ht.RemoveKey("burst")
+	ht.RecordRequestN("burst", 100)
+	if ht.IsHotspot("burst") {
+		t.Error("expected a removed key's observations to be forgotten")
+	}
+}
+
+func TestWithMinObservationsBurstDoesNotTakeSlot(t *testing.T) {
+	ht := NewHotspotTracker(1, 1).WithMinObservations(3)
+	ht.RecordRequestN("burst", 100)
+	for i := 0; i < 5; i++ {
+		ht.RecordRequest("steady")
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"steady"}) {
+		t.Errorf("expected [steady], got %v", got)
+	}
+
+	// Once observed often enough, the burst outranks steady
+	ht.RecordRequest("burst")
+	ht.RecordRequest("burst")
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"burst"}) {
+		t.Errorf("expected [burst], got %v", got)
+	}
+	if err := ht.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithMinObservationsSnapshot(t *testing.T) {
+	ht := NewHotspotTracker(2, 2).WithMinObservations(2)
+	ht.RecordRequestN("burst", 50)
+	ht.RecordBatch([]string{"a", "a", "b"})
+
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewHotspotTracker(2, 2).WithMinObservations(2)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected observations to survive a snapshot, got %v", got)
+	}
+}
+
+func TestWithMinObservationsFullAggregationAndResize(t *testing.T) {
+	ht := NewHotspotTracker(2, 4).WithMinObservations(2).WithFullAggregation()
+	ht.RecordRequestN("burst", 50)
+	ht.RecordBatch([]string{"a", "a", "b"})
+
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected [a], got %v", got)
+	}
+	if err := ht.Resize(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected observations to survive a resize, got %v", got)
+	}
+	if got := ht.Clone().GetHotspots(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("expected the clone to require observations too, got %v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package htracker
+
+import "time"
+
+// AggregateStats describes the cost and outcome of one aggregation across shards
+type AggregateStats struct {
+	// Duration is the wall time spent aggregating
+	Duration time.Duration
+	// ShardsProcessed is the number of shards scanned; shards that could not
+	// contribute to the top N are skipped and not counted
+	ShardsProcessed int
+	// KeysScanned is the number of candidate keys read from scanned shards
+	KeysScanned int
+	// Size is the number of hotspots in the result
+	Size int
+}
+
+// WithAggregationObserver registers fn to be called after every aggregation,
+// which with caching enabled is every cache rebuild. fn runs outside of all
+// tracker locks once the rebuild has completed.
+func (ht *HotspotTrackerOf[K]) WithAggregationObserver(fn func(AggregateStats)) *HotspotTrackerOf[K] {
+	ht.aggregationObserver = fn
+	return ht
+}
+
+// Aggregations returns the number of aggregations run so far, which with
+// caching enabled is the number of cache rebuilds
+func (ht *HotspotTrackerOf[K]) Aggregations() int64 {
+	return ht.aggregations.Load()
+}
+
+func (ht *HotspotTrackerOf[K]) notifyAggregation(start time.Time, stats AggregateStats) {
+	if ht.aggregationObserver == nil {
+		return
+	}
+	stats.Duration = time.Since(start)
+	ht.aggregationObserver(stats)
+}
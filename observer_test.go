@@ -0,0 +1,69 @@
+package htracker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregationObserver(t *testing.T) {
+	var observed []AggregateStats
+	ht := NewHotspotTracker(3, 4).WithAggregationObserver(func(stats AggregateStats) {
+		observed = append(observed, stats)
+	})
+
+	keys := []string{"a", "b", "c", "d", "e", "a", "a", "b"}
+	for _, key := range keys {
+		ht.RecordRequest(key)
+	}
+
+	ht.GetHotspots()
+	ht.IsHotspot("a")
+
+	if len(observed) != 2 {
+		t.Fatalf("expected 2 observed aggregations, got %d", len(observed))
+	}
+	for _, stats := range observed {
+		if stats.Size != 3 {
+			t.Errorf("expected result size 3, got %d", stats.Size)
+		}
+		if stats.ShardsProcessed < 1 || stats.ShardsProcessed > 4 {
+			t.Errorf("expected between 1 and 4 shards processed, got %d", stats.ShardsProcessed)
+		}
+		if stats.KeysScanned < stats.Size || stats.KeysScanned > 5 {
+			t.Errorf("expected between %d and 5 keys scanned, got %d", stats.Size, stats.KeysScanned)
+		}
+		if stats.Duration < 0 {
+			t.Errorf("expected non-negative duration, got %v", stats.Duration)
+		}
+	}
+}
+
+func TestAggregationObserverCanCallTracker(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+	defer ht.Close()
+
+	calls := 0
+	ht.WithCache(time.Hour).WithAggregationObserver(func(AggregateStats) {
+		calls++
+		// The observer runs outside the cache lock, so reading back is safe
+		ht.GetHotspots()
+	})
+
+	ht.RecordRequest("a")
+	ht.GetHotspots()
+	ht.GetHotspots()
+
+	if calls != 1 {
+		t.Errorf("expected 1 cache rebuild to be observed, got %d", calls)
+	}
+}
+
+func TestAggregations(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordRequest("a")
+	ht.GetHotspots()
+	ht.IsHotspot("a")
+	if n := ht.Aggregations(); n != 2 {
+		t.Errorf("expected 2 aggregations, got %d", n)
+	}
+}
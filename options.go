@@ -0,0 +1,122 @@
+package htracker
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// defaultTopN is the number of hotspots New tracks without a TopN option
+const defaultTopN = 10
+
+// Option configures a tracker built by New
+type Option func(*options)
+
+// options collects New's settings before the tracker is built, so that
+// every option is known before any background goroutine starts
+type options struct {
+	topN          int
+	numShards     int
+	hash          func(string) uint32
+	clock         Clock
+	minFrequency  int
+	cacheInterval time.Duration
+	cacheJitter   float64
+	maxStaleness  time.Duration
+	halfLife      time.Duration
+	keyTTL        time.Duration
+	logger        *slog.Logger
+}
+
+// TopN sets the number of hotspots to track. It defaults to 10.
+func TopN(n int) Option {
+	return func(o *options) { o.topN = n }
+}
+
+// NumShards sets the number of shards. It defaults to one per available
+// CPU, rounded up to a power of two.
+func NumShards(n int) Option {
+	return func(o *options) { o.numShards = n }
+}
+
+// Hash sets the hash used to assign keys to shards, as WithHashFunc. It
+// defaults to FNV1a.
+func Hash(hash func(string) uint32) Option {
+	return func(o *options) { o.hash = hash }
+}
+
+// TimeSource sets the clock the tracker reads time from, as WithClock. It
+// defaults to the system clock.
+func TimeSource(c Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// MinFrequency sets the frequency a key needs to count as a hotspot, as
+// WithMinFrequency
+func MinFrequency(min int) Option {
+	return func(o *options) { o.minFrequency = min }
+}
+
+// Cache caches the aggregated hotspots and refreshes them every interval,
+// as WithCache
+func Cache(interval time.Duration) Option {
+	return func(o *options) { o.cacheInterval = interval }
+}
+
+// CacheJitter randomizes each cache refresh by up to fraction of the Cache
+// interval, as WithCacheJitter
+func CacheJitter(fraction float64) Option {
+	return func(o *options) { o.cacheJitter = fraction }
+}
+
+// MaxStaleness rebuilds the cached hotspots on read once they are older
+// than d, as WithMaxStaleness
+func MaxStaleness(d time.Duration) Option {
+	return func(o *options) { o.maxStaleness = d }
+}
+
+// Decay makes frequencies decay with the given half-life, as WithDecay
+func Decay(halfLife time.Duration) Option {
+	return func(o *options) { o.halfLife = halfLife }
+}
+
+// KeyTTL drops keys that have not been recorded for d, as WithKeyTTL
+func KeyTTL(d time.Duration) Option {
+	return func(o *options) { o.keyTTL = d }
+}
+
+// Logger logs significant events at Debug level, as WithLogger
+func Logger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// New initializes a new HotspotTracker configured by opts. Unlike the
+// With methods, options can be given in any order: the tracker is fully
+// configured before the ticker goroutine of Cache, Decay or KeyTTL starts.
+// It panics if TopN or NumShards is not positive or Hash is nil.
+func New(opts ...Option) *HotspotTracker {
+	o := options{
+		topN:      defaultTopN,
+		numShards: nextPowerOfTwo(runtime.GOMAXPROCS(0)),
+		hash:      FNV1a,
+		clock:     realClock{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ht := NewHotspotTrackerOf(o.topN, o.numShards, o.hash).
+		WithClock(o.clock).
+		WithMinFrequency(o.minFrequency).
+		WithLogger(o.logger)
+	if o.halfLife > 0 {
+		ht.WithDecay(o.halfLife)
+	}
+	if o.keyTTL > 0 {
+		ht.WithKeyTTL(o.keyTTL)
+	}
+	if o.cacheInterval > 0 {
+		ht.WithCacheJitter(o.cacheJitter).WithMaxStaleness(o.maxStaleness).WithCache(o.cacheInterval)
+	}
+	return ht
+}
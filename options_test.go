@@ -0,0 +1,103 @@
+package htracker
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	ht := New()
+	if ht.topN != defaultTopN {
+		t.Errorf("expected topN %d, got %d", defaultTopN, ht.topN)
+	}
+	if expected := nextPowerOfTwo(runtime.GOMAXPROCS(0)); ht.numShards != expected {
+		t.Errorf("expected %d shards, got %d", expected, ht.numShards)
+	}
+	if _, ok := ht.clock.(realClock); !ok {
+		t.Errorf("expected the system clock, got %T", ht.clock)
+	}
+	if ht.withCache || ht.stop != nil {
+		t.Error("expected no cache and no ticker goroutine")
+	}
+	if got := ht.hash("a"); got != FNV1a("a") {
+		t.Errorf("expected FNV1a, got hash %d", got)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	hashed := 0
+	hash := func(key string) uint32 {
+		hashed++
+		return FNV1a(key)
+	}
+
+	// The clock comes after Cache, which With methods wouldn't allow
+	ht := New(Cache(time.Second), Hash(hash), TopN(1), NumShards(4), TimeSource(clock))
+	defer ht.Close()
+	if ht.topN != 1 || ht.numShards != 4 {
+		t.Errorf("expected topN 1 and 4 shards, got %d and %d", ht.topN, ht.numShards)
+	}
+
+	ht.RecordRequestN("a", 2)
+	if hashed == 0 {
+		t.Error("expected the Hash option to pick the shard")
+	}
+	if got := ht.GetHotspots(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("expected [a], got %v", got)
+	}
+
+	ht.RecordRequestN("b", 5)
+	if got := ht.GetHotspots(); got[0] != "a" {
+		t.Fatalf("expected the cached [a], got %v", got)
+	}
+	clock.Advance(time.Second)
+	for !ht.update.Load() {
+		runtime.Gosched()
+	}
+	if got := ht.GetHotspots(); got[0] != "b" {
+		t.Errorf("expected the fake clock to refresh the cache to [b], got %v", got)
+	}
+}
+
+func TestNewDecayAndTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := New(KeyTTL(time.Minute), Decay(time.Hour), MinFrequency(2), TimeSource(clock), NumShards(1))
+	defer ht.Close()
+
+	ht.RecordRequestN("a", 100)
+	ht.RecordRequest("b")
+	if got := ht.GetHotspots(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("expected MinFrequency to leave only [a], got %v", got)
+	}
+
+	clock.Advance(2 * time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := ht.GetFrequency("a"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a to expire after its TTL")
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"topN":      TopN(0),
+		"numShards": NumShards(-1),
+		"hash":      Hash(nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected an invalid %s to panic", name)
+				}
+			}()
+			New(opt)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package htracker
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Order is the order in which GetHotspotsSorted returns hotspots
+type Order int
+
+const (
+	// FreqDesc orders hotspots most frequent first, as GetHotspots does
+	FreqDesc Order = iota
+	// FreqAsc orders hotspots least frequent first
+	FreqAsc
+	// KeyAsc orders hotspots by key, smallest first
+	KeyAsc
+	// KeyDesc orders hotspots by key, largest first
+	KeyDesc
+)
+
+// GetHotspotsSorted returns the current hotspots in the given order. Keys
+// with the same frequency are ordered by key, smallest first, in both
+// frequency orders. Keys are compared as in frequency ties, by their
+// underlying kind: strings and numbers naturally, booleans false first,
+// arrays and structs element by element, and pointers and channels by
+// address. Interface keys compare by their dynamic values, ordered by type
+// name first when the types differ, with nil first.
+func (ht *HotspotTrackerOf[K]) GetHotspotsSorted(order Order) []K {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	hotspots := aggregateShard.sortedKeyFreqs()
+	switch order {
+	case FreqAsc:
+		slices.SortStableFunc(hotspots, func(a, b KeyFreqOf[K]) int {
+			return cmp.Compare(a.Frequency, b.Frequency)
+		})
+	case KeyAsc:
+		slices.SortFunc(hotspots, func(a, b KeyFreqOf[K]) int {
+			return compareKeys(a.Key, b.Key)
+		})
+	case KeyDesc:
+		slices.SortFunc(hotspots, func(a, b KeyFreqOf[K]) int {
+			return compareKeys(b.Key, a.Key)
+		})
+	}
+
+	keys := make([]K, len(hotspots))
+	for i, kf := range hotspots {
+		keys[i] = kf.Key
+	}
+	return keys
+}
@@ -0,0 +1,32 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetHotspotsSorted(t *testing.T) {
+	ht := NewHotspotTracker(5, 4)
+	for key, n := range map[string]int{"d": 3, "b": 3, "e": 1, "a": 5, "c": 2, "f": 1} {
+		ht.RecordRequestN(key, n)
+	}
+
+	// f ties e at 1 but loses the tiebreak for the last slot
+	tests := []struct {
+		order    Order
+		expected []string
+	}{
+		{FreqDesc, []string{"a", "b", "d", "c", "e"}},
+		{FreqAsc, []string{"e", "c", "b", "d", "a"}},
+		{KeyAsc, []string{"a", "b", "c", "d", "e"}},
+		{KeyDesc, []string{"e", "d", "c", "b", "a"}},
+	}
+	for _, tt := range tests {
+		if got := ht.GetHotspotsSorted(tt.order); !slices.Equal(got, tt.expected) {
+			t.Errorf("order %d: expected %v, got %v", tt.order, tt.expected, got)
+		}
+	}
+	if got := ht.GetHotspots(); !slices.Equal(got, tests[0].expected) {
+		t.Errorf("expected GetHotspots to match FreqDesc, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package htracker
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithPrefixRollup also counts every path-like key under its first depth
+// segments, so that /api/v1/users/123 and /api/v1/orders/7 both count
+// towards /api/v1 at depth 2. Prefixes are ranked apart from the exact
+// keys, in a tracker of their own with the same topN, shard count, hash
+// and clock, and are read with GetPrefixHotspots. Keys with fewer than
+// depth segments have no prefix. Windows, decay, leases and the other
+// options are not applied to the prefixes, and RecordLease doesn't count
+// towards them. Prefixes only make sense for keys whose underlying type is
+// string, so it panics for any other key type, and it must be called
+// before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithPrefixRollup(depth int) *HotspotTrackerOf[K] {
+	if !isStringKey[K]() {
+		panic(fmt.Sprintf("htracker: WithPrefixRollup requires string keys, got %v", reflect.TypeFor[K]()))
+	}
+	if depth <= 0 {
+		panic(fmt.Sprintf("htracker: prefix depth must be positive, got %d", depth))
+	}
+	ht.prefixes = NewHotspotTrackerOf(ht.topN, ht.numShards, ht.hash).WithClock(ht.clock)
+	ht.prefixDepth = depth
+	return ht
+}
+
+// GetPrefixHotspots returns the hottest key prefixes, most frequent first,
+// or nil without WithPrefixRollup
+func (ht *HotspotTrackerOf[K]) GetPrefixHotspots() []K {
+	if ht.prefixes == nil {
+		return nil
+	}
+	return ht.prefixes.GetHotspots()
+}
+
+// recordPrefix counts n requests for the prefix of an already normalized
+// key, if prefixes are rolled up and the key is deep enough to have one
+func (ht *HotspotTrackerOf[K]) recordPrefix(key K, n int) {
+	if ht.prefixes == nil {
+		return
+	}
+	if prefix, ok := rollupPrefix(keyString(key), ht.prefixDepth); ok {
+		ht.prefixes.record(stringKey[K](prefix), n)
+	}
+}
+
+// rollupPrefix truncates key to its first depth slash-separated segments,
+// ignoring a leading slash, and reports whether key has that many
+func rollupPrefix(key string, depth int) (string, bool) {
+	i := 0
+	if strings.HasPrefix(key, "/") {
+		i = 1
+	}
+	for d := 0; d < depth; d++ {
+		j := strings.IndexByte(key[i:], '/')
+		if j < 0 {
+			// The last segment ends the key, so the key is its own prefix
+			if d == depth-1 && i < len(key) {
+				return key, true
+			}
+			return "", false
+		}
+		i += j + 1
+	}
+	return key[:i-1], true
+}
@@ -0,0 +1,78 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithPrefixRollup(t *testing.T) {
+	ht := NewHotspotTracker(2, 4).WithPrefixRollup(2)
+	for path, n := range map[string]int{
+		"/api/v1/users/1":   3,
+		"/api/v1/users/2":   2,
+		"/api/v1/orders/1":  2,
+		"/api/v2/users/1":   4,
+		"/static/js/app.js": 1,
+		"/health":           5,
+	} {
+		ht.RecordRequestN(path, n)
+	}
+	ht.RecordBatch([]string{"/static/js/lib.js", "/static/js/lib.js"})
+
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"/health", "/api/v2/users/1"}) {
+		t.Errorf("expected exact hotspots [/health /api/v2/users/1], got %v", got)
+	}
+	// /api/v1 has 7, /api/v2 4 and /static/js 3; /health is too shallow
+	if got := ht.GetPrefixHotspots(); !slices.Equal(got, []string{"/api/v1", "/api/v2"}) {
+		t.Errorf("expected prefix hotspots [/api/v1 /api/v2], got %v", got)
+	}
+	if freq, _ := ht.prefixes.GetFrequency("/static/js"); freq != 3 {
+		t.Errorf("expected /static/js to count 3 including the batch, got %d", freq)
+	}
+	if NewHotspotTracker(2, 1).GetPrefixHotspots() != nil {
+		t.Error("expected no prefix hotspots without WithPrefixRollup")
+	}
+}
+
+func TestRollupPrefix(t *testing.T) {
+	tests := []struct {
+		key    string
+		depth  int
+		prefix string
+		ok     bool
+	}{
+		{"/api/v1/users/123", 2, "/api/v1", true},
+		{"/api/v1/users/123", 1, "/api", true},
+		{"api/v1/users", 2, "api/v1", true},
+		{"/api/v1", 2, "/api/v1", true},
+		{"/api/v1/", 2, "/api/v1", true},
+		{"/api", 2, "", false},
+		{"/api/", 2, "", false},
+		{"", 1, "", false},
+	}
+	for _, tt := range tests {
+		prefix, ok := rollupPrefix(tt.key, tt.depth)
+		if prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("rollupPrefix(%q, %d): expected %q, %v, got %q, %v", tt.key, tt.depth, tt.prefix, tt.ok, prefix, ok)
+		}
+	}
+}
+
+func TestWithPrefixRollupNamedString(t *testing.T) {
+	ht := NewHotspotTrackerOf(2, 2, func(k routeKey) uint32 { return uint32(len(k)) }).WithPrefixRollup(1)
+	ht.RecordRequest("/api/users")
+	ht.RecordRequest("/api/orders")
+
+	if got := ht.GetPrefixHotspots(); len(got) != 1 || got[0] != "/api" {
+		t.Errorf("expected [/api], got %v", got)
+	}
+}
+
+func TestWithPrefixRollupNonString(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected WithPrefixRollup to panic for int keys")
+		}
+	}()
+	NewHotspotTrackerOf(1, 1, func(k int) uint32 { return uint32(k) }).WithPrefixRollup(1)
+}
@@ -0,0 +1,103 @@
+package htracker
+
+import (
+	"log/slog"
+	"time"
+)
+
+// rebalanceInterval is how often WithAutoRebalance checks the shards' skew
+const rebalanceInterval = 10 * time.Second
+
+// SkewReport describes how unevenly keys are spread over the shards
+type SkewReport struct {
+	// Skew is the largest shard's key count over the mean: 1 when keys are
+	// spread evenly, up to the number of shards when one shard holds them
+	// all. A tracker without keys has a skew of 1.
+	Skew float64
+	// MaxShard is the index of the shard holding the most keys, and
+	// MaxKeys how many it holds
+	MaxShard int
+	MaxKeys  int
+	// MeanKeys is the mean number of keys per shard
+	MeanKeys float64
+}
+
+// Skew reports how unevenly keys are spread over the shards, from the same
+// per-shard counts as ShardStats
+func (ht *HotspotTrackerOf[K]) Skew() SkewReport {
+	stats := ht.ShardStats()
+	report := SkewReport{Skew: 1}
+	total := 0
+	for _, stat := range stats {
+		total += stat.Keys
+		if stat.Keys > report.MaxKeys {
+			report.MaxShard, report.MaxKeys = stat.Index, stat.Keys
+		}
+	}
+	report.MeanKeys = float64(total) / float64(len(stats))
+	if total > 0 {
+		report.Skew = float64(report.MaxKeys) / report.MeanKeys
+	}
+	return report
+}
+
+// WithAutoRebalance checks the shards' skew every 10 seconds from the
+// tracker's ticker, which Close stops, and rebalances them when Skew
+// exceeds threshold. A rebalance reshards every key like Resize, keeping
+// the shard count, after mixing a new salt into the key hashes, so keys a
+// poor hash function crowded into one shard are spread out. No count is
+// lost, but snapshots then only restore into a tracker rebalanced the same
+// number of times. Trackers that Resize cannot handle are never
+// rebalanced.
+func (ht *HotspotTrackerOf[K]) WithAutoRebalance(threshold float64) *HotspotTrackerOf[K] {
+	ht.addTask(rebalanceInterval, func() {
+		before := ht.Skew()
+		if before.Skew <= threshold {
+			return
+		}
+		if _, _, err := ht.resize(0, true); err != nil {
+			return
+		}
+		ht.rebalances.Add(1)
+		ht.lastRebalance.Store(ht.clock.Now().UnixNano())
+		if ht.debugEnabled() {
+			ht.logDebug("htracker: shards rebalanced",
+				slog.Float64("skew_before", before.Skew),
+				slog.Float64("skew_after", ht.Skew().Skew))
+		}
+	})
+	return ht
+}
+
+// RebalanceCount returns how many times WithAutoRebalance has rebalanced
+// the shards
+func (ht *HotspotTrackerOf[K]) RebalanceCount() int64 {
+	return ht.rebalances.Load()
+}
+
+// LastRebalance returns when WithAutoRebalance last rebalanced the shards,
+// or the zero time if it never has
+func (ht *HotspotTrackerOf[K]) LastRebalance() time.Time {
+	if ht.rebalances.Load() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ht.lastRebalance.Load())
+}
+
+// saltedSharder returns the tracker's sharder for numShards shards, salted
+// with shardSalt once the shards have been rebalanced
+func (ht *HotspotTrackerOf[K]) saltedSharder(numShards int) Sharder {
+	sharder := ht.newSharder(numShards)
+	if ht.shardSalt == 0 {
+		return sharder
+	}
+	return saltSharder{salt: ht.shardSalt * 0x9e3779b9, Sharder: sharder}
+}
+
+// saltSharder shards a hash mixed with a salt
+type saltSharder struct {
+	salt uint32
+	Sharder
+}
+
+func (s saltSharder) Shard(hash uint32) int { return s.Sharder.Shard(mix32(hash ^ s.salt)) }
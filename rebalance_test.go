@@ -0,0 +1,92 @@
+package htracker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crowdingHash maps "keyN" to 4N, which a four-shard mask puts entirely in
+// shard 0
+func crowdingHash(key string) uint32 {
+	n, _ := strconv.Atoi(strings.TrimPrefix(key, "key"))
+	return uint32(4 * n)
+}
+
+func TestSkew(t *testing.T) {
+	ht := NewHotspotTracker(5, 4)
+	if report := ht.Skew(); report.Skew != 1 || report.MaxKeys != 0 {
+		t.Errorf("expected an empty tracker to be even, got %+v", report)
+	}
+
+	ht = NewHotspotTracker(5, 4).WithHashFunc(crowdingHash)
+	for i := 0; i < 100; i++ {
+		ht.RecordRequest(fmt.Sprintf("key%d", i))
+	}
+	report := ht.Skew()
+	if report.Skew != 4 || report.MaxShard != 0 || report.MaxKeys != 100 || report.MeanKeys != 25 {
+		t.Errorf("expected every key in shard 0, got %+v", report)
+	}
+}
+
+func TestWithAutoRebalance(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(5, 4).WithClock(clock).WithHashFunc(crowdingHash).WithAutoRebalance(1.5)
+	defer ht.Close()
+	tick := clock.ticker(ht, rebalanceInterval)
+
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		counts[key] = i%13 + 1
+		ht.RecordRequestN(key, counts[key])
+	}
+	hotspots := ht.GetHotspots()
+	var snapshot bytes.Buffer
+	if err := ht.Snapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	tick()
+	if got := ht.RebalanceCount(); got != 1 {
+		t.Fatalf("expected 1 rebalance, got %d", got)
+	}
+	if got := ht.LastRebalance(); !got.Equal(clock.Now()) {
+		t.Errorf("expected the rebalance at %v, got %v", clock.Now(), got)
+	}
+	if skew := ht.Skew().Skew; skew > 1.5 {
+		t.Errorf("expected the rebalance to bring the skew to 1.5 or less, got %.2f", skew)
+	}
+
+	// No count is lost, and the keys are found in their new shards
+	for key, expected := range counts {
+		if freq, ok := ht.GetFrequency(key); !ok || freq != expected {
+			t.Errorf("expected %q to keep %d, got (%d, %v)", key, expected, freq, ok)
+		}
+	}
+	if got := fmt.Sprint(ht.GetHotspots()); got != fmt.Sprint(hotspots) {
+		t.Errorf("expected hotspots %v, got %s", hotspots, got)
+	}
+
+	// An even tracker is left alone
+	tick()
+	if got := ht.RebalanceCount(); got != 1 {
+		t.Errorf("expected no further rebalance, got %d", got)
+	}
+
+	// A snapshot from before the rebalance no longer matches the shards
+	if err := ht.Restore(&snapshot); !errors.Is(err, ErrShardMismatch) {
+		t.Errorf("expected ErrShardMismatch, got %v", err)
+	}
+}
+
+func TestLastRebalanceNever(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	if ht.RebalanceCount() != 0 || !ht.LastRebalance().IsZero() {
+		t.Errorf("expected no rebalances, got %d at %v", ht.RebalanceCount(), ht.LastRebalance())
+	}
+}
@@ -0,0 +1,104 @@
+package htracker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrResizeUnsupported is returned by Resize for trackers whose per-key
+// state cannot be moved between shards
+var ErrResizeUnsupported = errors.New("htracker: cannot resize a tracker with a window, decay or sketch")
+
+// Resize redistributes every key over numShards new shards, for example to
+// spread lock contention as load grows. Keys keep their counts and their
+// first- and last-seen times; each new shard then selects its own
+// top N. Recording and reading wait while the shards are swapped, so no
+// request is lost or counted twice. Trackers using WithWindow, WithDecay or
+// WithSketch cannot be resized and return ErrResizeUnsupported.
+func (ht *HotspotTrackerOf[K]) Resize(numShards int) error {
+	if numShards <= 0 {
+		return fmt.Errorf("htracker: numShards must be positive, got %d", numShards)
+	}
+
+	from, keys, err := ht.resize(numShards, false)
+	if err != nil {
+		return err
+	}
+	if ht.debugEnabled() {
+		ht.logDebug("htracker: shards resized",
+			slog.Int("from", from),
+			slog.Int("to", numShards),
+			slog.Int("keys", keys))
+	}
+	return nil
+}
+
+// resize is Resize once numShards is validated, where 0 keeps the current
+// number of shards. With reseed it also changes the salt keys are sharded
+// with. It returns the previous number of shards and the number of keys
+// moved.
+func (ht *HotspotTrackerOf[K]) resize(numShards int, reseed bool) (from, keys int, err error) {
+	ht.resizeMu.Lock()
+	defer ht.resizeMu.Unlock()
+
+	template := ht.shards[0]
+	if template.window != nil || template.weights != nil || template.sketch != nil {
+		return 0, 0, ErrResizeUnsupported
+	}
+
+	from = ht.numShards
+	if numShards == 0 {
+		numShards = from
+	}
+	ht.numShards = numShards
+	if reseed {
+		ht.shardSalt++
+	}
+	ht.sharder = ht.saltedSharder(numShards)
+
+	// Nothing else can use the old shards while resizeMu is held
+	entries := make([][]snapshotEntry[K], numShards)
+	for _, s := range ht.shards {
+		for key, kf := range s.keyFreqs {
+			i := ht.shardIndex(key)
+			keys++
+			entries[i] = append(entries[i], snapshotEntry[K]{
+				Key:          key,
+				Frequency:    kf.Frequency,
+				Weight:       kf.Weight,
+				FirstSeen:    kf.FirstSeen,
+				LastSeen:     kf.LastSeen,
+				Observations: s.observations[key],
+			})
+		}
+	}
+
+	shards := make([]*shard[K], numShards)
+	for i := range shards {
+		s := newShard[K](ht.topN)
+		s.checkInvariants = template.checkInvariants
+		s.universe = template.universe
+		s.maxKeys = template.maxKeys
+		if template.history != nil {
+			s.history = newDemotionHistory[K](template.history.max)
+		}
+		s.floatWeights = template.floatWeights
+		if template.timestamps {
+			s.timestamps = true
+			s.now = template.now
+		}
+		if template.observations != nil {
+			s.observations = make(map[K]int, len(entries[i]))
+			s.minObservations = template.minObservations
+		}
+		s.load(entries[i])
+		shards[i] = s
+	}
+	ht.shards = shards
+
+	if ht.withCache {
+		ht.update.Store(true)
+	}
+	return from, keys, nil
+}
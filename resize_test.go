@@ -0,0 +1,78 @@
+package htracker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+	counts := map[string]int{}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		counts[key] = i%7 + 1
+		ht.RecordRequestN(key, counts[key])
+	}
+	hotspots := ht.GetHotspots()
+
+	for _, numShards := range []int{8, 3, 1} {
+		if err := ht.Resize(numShards); err != nil {
+			t.Fatalf("resizing to %d shards: %v", numShards, err)
+		}
+		if got := len(ht.ShardStats()); got != numShards {
+			t.Errorf("expected %d shards, got %d", numShards, got)
+		}
+		for key, expected := range counts {
+			if freq, ok := ht.GetFrequency(key); !ok || freq != expected {
+				t.Errorf("%d shards: expected %q to keep %d, got (%d, %v)", numShards, key, expected, freq, ok)
+			}
+		}
+		if got := ht.GetHotspots(); !slices.Equal(got, hotspots) {
+			t.Errorf("%d shards: expected hotspots %v, got %v", numShards, hotspots, got)
+		}
+	}
+
+	if err := ht.Resize(0); err == nil {
+		t.Error("expected an error resizing to 0 shards")
+	}
+}
+
+func TestResizeConcurrentRecord(t *testing.T) {
+	ht := NewHotspotTracker(5, 2)
+
+	const workers, perWorker = 4, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ht.RecordRequest(fmt.Sprintf("key%d", i%10))
+			}
+		}()
+	}
+	for _, numShards := range []int{4, 16, 1, 8} {
+		if err := ht.Resize(numShards); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if freq, _ := ht.GetFrequency(key); freq != workers*perWorker/10 {
+			t.Errorf("expected %q to count %d requests across resizes, got %d", key, workers*perWorker/10, freq)
+		}
+	}
+}
+
+func TestResizeUnsupported(t *testing.T) {
+	ht := NewHotspotTracker(3, 2).WithWindow(time.Minute)
+	if err := ht.Resize(4); !errors.Is(err, ErrResizeUnsupported) {
+		t.Errorf("expected ErrResizeUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package htracker
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// WithSampleRate makes RecordRequest, RecordRequestN, RecordRequestFloat,
+// RecordRequestAt and RecordCooccurrence process only about a fraction rate
+// of their calls, chosen at random before the key is normalized or hashed,
+// and count each processed call 1/rate times over so that frequencies stay
+// unbiased estimates. rate is rounded so that 1/rate is a whole number: 0.1
+// samples one call in 10, and 0.3 one in 3. Frequencies then grow in steps
+// of 1/rate, and a key with few requests may be missed entirely. Skipped
+// calls are counted in Events as ReasonSampled. RecordBatch, AddCounts and
+// RecordLease are not sampled. It panics unless 0 < rate <= 1.
+func (ht *HotspotTrackerOf[K]) WithSampleRate(rate float64) *HotspotTrackerOf[K] {
+	if !(rate > 0 && rate <= 1) {
+		panic(fmt.Sprintf("htracker: sample rate must be in (0, 1], got %v", rate))
+	}
+	ht.sampleEvery = max(int(math.Round(1/rate)), 1)
+	return ht
+}
+
+// WithRandSource makes the tracker's random choices, such as which calls
+// WithSampleRate keeps and WithCacheJitter's delays, with src instead of
+// the randomly seeded global source, so that a fixed seed gives
+// reproducible results. src need not be safe for concurrent use: the
+// tracker serializes its calls, which makes sampling slower under
+// contention than with the global source.
+func (ht *HotspotTrackerOf[K]) WithRandSource(src rand.Source) *HotspotTrackerOf[K] {
+	ht.rand = &lockedRand{r: rand.New(src)}
+	return ht
+}
+
+// lockedRand makes a rand.Rand safe for concurrent use
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) IntN(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.IntN(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// float64 returns a random float64 in [0, 1) from the tracker's source
+func (ht *HotspotTrackerOf[K]) float64() float64 {
+	if ht.rand != nil {
+		return ht.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// intN returns a random int in [0, n) from the tracker's source
+func (ht *HotspotTrackerOf[K]) intN(n int) int {
+	if ht.rand != nil {
+		return ht.rand.IntN(n)
+	}
+	return rand.IntN(n)
+}
+
+// sample reports whether a call should be processed, and if so the weight
+// it stands for
+func (ht *HotspotTrackerOf[K]) sample() (int, bool) {
+	if ht.sampleEvery <= 1 {
+		return 1, true
+	}
+	if ht.intN(ht.sampleEvery) != 0 {
+		ht.events.sampledOut.Add(1)
+		return 0, false
+	}
+	return ht.sampleEvery, true
+}
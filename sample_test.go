@@ -0,0 +1,92 @@
+package htracker
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestWithSampleRate(t *testing.T) {
+	const keys, perKey = 20, 10000
+	ht := NewHotspotTracker(keys, 4).WithSampleRate(0.1)
+	for i := 0; i < perKey; i++ {
+		for k := 0; k < keys; k++ {
+			ht.RecordRequest(fmt.Sprintf("key%d", k))
+		}
+	}
+
+	// Each estimate is 10 times a Binomial(10000, 0.1) count, so its
+	// standard deviation is 10*sqrt(900) = 300
+	const tolerance = 5 * 300
+	var total int
+	for k := 0; k < keys; k++ {
+		freq, _ := ht.GetFrequency(fmt.Sprintf("key%d", k))
+		if math.Abs(float64(freq-perKey)) > tolerance {
+			t.Errorf("key%d: expected about %d, got %d", k, perKey, freq)
+		}
+		if freq%10 != 0 {
+			t.Errorf("key%d: expected a multiple of 10, got %d", k, freq)
+		}
+		total += freq
+	}
+	if got := ht.TotalRequests(); got != int64(total) {
+		t.Errorf("expected the total to match the estimates' sum %d, got %d", total, got)
+	}
+}
+
+func TestWithSampleRateRounding(t *testing.T) {
+	for rate, every := range map[float64]int{1: 1, 0.5: 2, 0.3: 3, 0.1: 10, 0.001: 1000} {
+		if got := NewHotspotTracker(1, 1).WithSampleRate(rate).sampleEvery; got != every {
+			t.Errorf("rate %v: expected one call in %d, got %d", rate, every, got)
+		}
+	}
+	for _, rate := range []float64{0, -1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected rate %v to panic", rate)
+				}
+			}()
+			NewHotspotTracker(1, 1).WithSampleRate(rate)
+		}()
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	record := func(seed uint64) map[string]int {
+		ht := NewHotspotTracker(10, 4).WithSampleRate(0.1).WithRandSource(rand.NewPCG(seed, 1))
+		for i := 0; i < 5000; i++ {
+			ht.RecordRequest(fmt.Sprintf("key%d", i%10))
+		}
+		return ht.GetAllFrequencies()
+	}
+
+	first, second := record(42), record(42)
+	if !maps.Equal(first, second) {
+		t.Errorf("expected identically seeded trackers to agree, got %v and %v", first, second)
+	}
+	if other := record(43); maps.Equal(first, other) {
+		t.Errorf("expected another seed to sample differently, both got %v", first)
+	}
+}
+
+func TestWithSampleRateEvents(t *testing.T) {
+	ht := NewHotspotTracker(10, 4).WithSampleRate(0.1).WithRandSource(rand.NewPCG(3, 4))
+	for i := 0; i < 1000; i++ {
+		ht.RecordRequest("a")
+	}
+
+	freq, _ := ht.GetFrequency("a")
+	var dropped int64
+	for _, ev := range ht.Events() {
+		if ev.Reason == ReasonSampled {
+			dropped = ev.Count
+		}
+	}
+	// Every call is either kept and counted 10 times or dropped
+	if kept := int64(freq / 10); kept+dropped != 1000 {
+		t.Errorf("expected %d kept and %d dropped calls to add up to 1000", kept, dropped)
+	}
+}
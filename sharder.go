@@ -0,0 +1,93 @@
+package htracker
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// ringReplicas is the number of points each shard owns on a hash ring
+const ringReplicas = 64
+
+// Sharder picks the shard of a key from the key's hash, as computed by the
+// tracker's hash function
+type Sharder interface {
+	// Shard returns a shard index in [0, numShards) for a key hash
+	Shard(hash uint32) int
+}
+
+// NewModuloSharder returns the default Sharder for numShards shards, which
+// takes the hash modulo numShards, using a mask when numShards is a power
+// of two. Changing the shard count moves almost every key.
+func NewModuloSharder(numShards int) Sharder {
+	if numShards > 1 && numShards&(numShards-1) == 0 {
+		return maskSharder(numShards - 1)
+	}
+	return moduloSharder(numShards)
+}
+
+type maskSharder uint32
+
+func (m maskSharder) Shard(hash uint32) int { return int(hash & uint32(m)) }
+
+type moduloSharder uint32
+
+// Shard reduces in uint32 so the index can't go negative where int is 32-bit
+func (n moduloSharder) Shard(hash uint32) int { return int(hash % uint32(n)) }
+
+// NewRingSharder returns a consistent-hashing Sharder for numShards shards.
+// Each shard owns 64 points on a hash ring and a key belongs to the shard
+// owning the first point at or after its hash. Growing or shrinking the
+// shard count with Resize then only moves the keys of the added or removed
+// shards' points, about 1/numShards of them per shard, at the cost of a
+// binary search per lookup.
+func NewRingSharder(numShards int) Sharder {
+	ring := make(ringSharder, 0, numShards*ringReplicas)
+	for shard := 0; shard < numShards; shard++ {
+		for replica := 0; replica < ringReplicas; replica++ {
+			ring = append(ring, ringPoint{hash: mix32(uint32(shard)<<16 | uint32(replica)), shard: shard})
+		}
+	}
+	slices.SortFunc(ring, func(a, b ringPoint) int { return cmp.Compare(a.hash, b.hash) })
+	return ring
+}
+
+// ringSharder is a hash ring sorted by point hash
+type ringSharder []ringPoint
+
+type ringPoint struct {
+	hash  uint32
+	shard int
+}
+
+func (r ringSharder) Shard(hash uint32) int {
+	// Remix the hash so that keys spread over the ring even when the
+	// tracker's hash has weak high bits
+	hash = mix32(hash)
+	i := sort.Search(len(r), func(i int) bool { return r[i].hash >= hash })
+	if i == len(r) {
+		i = 0
+	}
+	return r[i].shard
+}
+
+// WithSharder makes the tracker pick shards with a Sharder built by
+// newSharder for its shard count, now and again after every Resize. For
+// example WithSharder(NewRingSharder) keeps most keys in place across
+// resizes. It must be called before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithSharder(newSharder func(numShards int) Sharder) *HotspotTrackerOf[K] {
+	ht.newSharder = newSharder
+	ht.sharder = newSharder(ht.numShards)
+	return ht
+}
+
+// mix32 is the MurmurHash3 finalizer, which spreads every input bit over
+// the whole output
+func mix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,52 @@
+package htracker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingSharder(t *testing.T) {
+	ring := NewRingSharder(8)
+	counts := make([]int, 8)
+	for i := 0; i < 8000; i++ {
+		shard := ring.Shard(FNV1a(fmt.Sprintf("key%d", i)))
+		if shard < 0 || shard >= 8 {
+			t.Fatalf("expected a shard in [0, 8), got %d", shard)
+		}
+		counts[shard]++
+	}
+	for shard, n := range counts {
+		if n < 500 || n > 1500 {
+			t.Errorf("expected shard %d to get roughly 1000 of 8000 keys, got %d", shard, n)
+		}
+	}
+}
+
+func TestResizeRingSharder(t *testing.T) {
+	ht := NewHotspotTracker(3, 8).WithSharder(NewRingSharder)
+	keys := make([]string, 2000)
+	before := make([]int, len(keys))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		ht.RecordRequest(keys[i])
+		before[i] = ht.shardIndex(keys[i])
+	}
+
+	if err := ht.Resize(9); err != nil {
+		t.Fatal(err)
+	}
+	moved := 0
+	for i, key := range keys {
+		if ht.shardIndex(key) != before[i] {
+			moved++
+		}
+		if freq, _ := ht.GetFrequency(key); freq != 1 {
+			t.Errorf("expected %q to keep its count, got %d", key, freq)
+		}
+	}
+	// Only keys taken over by the new shard move, about 1/9 of them.
+	// Modulo sharding would move about 8/9.
+	if moved > len(keys)/5 {
+		t.Errorf("expected at most %d of %d keys to move, got %d", len(keys)/5, len(keys), moved)
+	}
+}
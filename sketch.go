@@ -0,0 +1,154 @@
+package htracker
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// WithSketch switches the tracker to an approximate mode for very high key
+// cardinality. Each shard counts requests in a Count-Min Sketch of depth
+// rows by width counters and keeps only its top-N candidates in its key
+// map, so memory no longer grows with the number of distinct keys. A key
+// outside the heap is admitted once its estimate exceeds the heap minimum.
+//
+// Estimates never undercount. With probability at least 1-e^-depth, a
+// key's estimate exceeds its true count by at most e/width times the
+// requests recorded in its shard, so doubling width halves the error and
+// each extra row makes a larger error about e times less likely. The
+// sketch takes width*depth ints per shard.
+//
+// GetFrequency reports estimates for keys outside the heap, and RemoveKey
+// only removes a key from the heap, since counts can't be taken back out
+// of a sketch. It must be called before any request is recorded, and can't
+// be combined with WithWindow, WithDecay, WithLazyDecay or
+// WithMinObservations: combining them panics, whichever is called first. It
+// also panics unless width and depth are positive.
+func (ht *HotspotTrackerOf[K]) WithSketch(width, depth int) *HotspotTrackerOf[K] {
+	if width <= 0 || depth <= 0 {
+		panic(fmt.Sprintf("htracker: sketch width and depth must be positive, got width %d and depth %d", width, depth))
+	}
+	// Hash through ht so that a later WithHashFunc is picked up too
+	hash := func(key K) uint32 { return ht.hash(key) }
+	for _, s := range ht.shards {
+		s.sketch = newCountMinSketch(width, depth)
+		s.sketchHash = hash
+	}
+	ht.checkFloatWeights()
+	ht.checkSketch()
+	return ht
+}
+
+// checkSketch panics if the sketch was combined with a mode that keeps
+// exact per-key state the sketch can't provide
+func (ht *HotspotTrackerOf[K]) checkSketch() {
+	s := ht.shards[0]
+	if s.sketch == nil {
+		return
+	}
+	switch {
+	case s.window != nil:
+		panic("htracker: WithSketch cannot be combined with WithWindow")
+	case s.weights != nil:
+		panic("htracker: WithSketch cannot be combined with WithDecay or WithLazyDecay")
+	case s.observations != nil:
+		panic("htracker: WithSketch cannot be combined with WithMinObservations")
+	}
+}
+
+// countMinSketch is a Count-Min Sketch with conservative update
+type countMinSketch struct {
+	width int
+	rows  [][]int
+	total int
+}
+
+func newCountMinSketch(width, depth int) *countMinSketch {
+	rows := make([][]int, depth)
+	for i := range rows {
+		rows[i] = make([]int, width)
+	}
+	return &countMinSketch{width: width, rows: rows}
+}
+
+// column picks a key's counter in row. The key's hash is remixed with a
+// per-row seed, since keys sharing a shard also share the hash bits that
+// picked the shard.
+func (c *countMinSketch) column(hash uint32, row int) int {
+	h := mix32(hash ^ (uint32(row)*0x9e3779b9 + 0x7f4a7c15))
+	return int(h % uint32(c.width))
+}
+
+// estimate returns the smallest counter for hash, an upper bound on its count
+func (c *countMinSketch) estimate(hash uint32) int {
+	est := math.MaxInt
+	for row := range c.rows {
+		est = min(est, c.rows[row][c.column(hash, row)])
+	}
+	return est
+}
+
+// add adds n to the count for hash and returns the new estimate. Only the
+// counters that would otherwise fall below the new estimate are raised,
+// which keeps overestimates smaller than incrementing every row.
+func (c *countMinSketch) add(hash uint32, n int) int {
+	est := c.estimate(hash) + n
+	for row := range c.rows {
+		col := c.column(hash, row)
+		c.rows[row][col] = max(c.rows[row][col], est)
+	}
+	c.total += n
+	return est
+}
+
+// errorBound returns how much an estimate may exceed the true count with
+// probability at least 1-e^-depth
+func (c *countMinSketch) errorBound() int {
+	return int(math.Ceil(math.E / float64(c.width) * float64(c.total)))
+}
+
+// recordSketch records a request of weight n in sketch mode, where keyFreqs
+// holds only the heap's keys and, with WithHotspotHistory, the remembered
+// former hotspots, which are counted exactly. The caller must hold s.mu.
+func (s *shard[K]) recordSketch(key K, n int) heapChange[K] {
+	var change heapChange[K]
+	est := s.sketch.add(s.sketchHash(key), n)
+
+	if kf, exists := s.keyFreqs[key]; exists {
+		if s.history != nil {
+			kf.Frequency += n
+		} else {
+			kf.Frequency = est
+		}
+		if kf.Index >= 0 {
+			heap.Fix(&s.minHeap, kf.Index)
+			s.observeFrequency(kf.Frequency)
+		} else if admitted, evicted := processKeyFreq(s, kf); admitted {
+			change.admitted = &KeyFreqOf[K]{Key: key, Frequency: kf.Frequency, Index: -1}
+			if evicted != nil {
+				change.evicted = &KeyFreqOf[K]{Key: evicted.Key, Frequency: evicted.Frequency, Index: -1}
+			}
+		}
+		return change
+	}
+	if len(s.minHeap) >= s.topN && (len(s.minHeap) == 0 || est <= s.minHeap[0].Frequency) {
+		return change
+	}
+
+	if len(s.minHeap) >= s.topN {
+		evicted := heap.Pop(&s.minHeap).(*KeyFreqOf[K])
+		if s.history != nil {
+			s.demoteLocked(evicted)
+		} else {
+			delete(s.keyFreqs, evicted.Key)
+		}
+		change.evicted = &KeyFreqOf[K]{Key: evicted.Key, Frequency: evicted.Frequency, Index: -1}
+	}
+	kf := &KeyFreqOf[K]{Key: key, Frequency: est}
+	heap.Push(&s.minHeap, kf)
+	s.keyFreqs[key] = kf
+	s.keyCount.Store(int64(len(s.keyFreqs)))
+	s.observeFrequency(est)
+	change.admitted = &KeyFreqOf[K]{Key: key, Frequency: est, Index: -1}
+	return change
+}
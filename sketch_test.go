@@ -0,0 +1,103 @@
+package htracker
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSketchZipf(t *testing.T) {
+	const topN = 10
+	ht := NewHotspotTracker(topN, 4).WithSketch(1024, 4)
+
+	rng := rand.New(rand.NewSource(42))
+	zipf := rand.NewZipf(rng, 1.2, 1, 20000)
+	exact := make(map[string]int)
+	for i := 0; i < 200000; i++ {
+		key := fmt.Sprintf("key%d", zipf.Uint64())
+		exact[key]++
+		ht.RecordRequest(key)
+	}
+
+	keys := make([]string, 0, len(exact))
+	for key := range exact {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return exact[keys[i]] > exact[keys[j]] })
+
+	// The clearly separated head of the distribution is reported
+	for _, key := range keys[:5] {
+		if !ht.IsHotspot(key) {
+			t.Errorf("expected true top key %q (%d requests) to be a hotspot, got %v", key, exact[key], ht.GetHotspots())
+		}
+	}
+
+	// Estimates never undercount and stay within the error bound
+	for _, est := range ht.GetHotspotsWithCI() {
+		if truth := exact[est.Key]; truth < est.LowerBound || truth > est.UpperBound {
+			t.Errorf("expected %q's true count %d within [%d, %d]", est.Key, truth, est.LowerBound, est.UpperBound)
+		}
+	}
+	if freq, ok := ht.GetFrequency(keys[len(keys)-1]); !ok || freq < exact[keys[len(keys)-1]] {
+		t.Errorf("expected a cold key to report an estimate of at least its count, got (%d, %v)", freq, ok)
+	}
+
+	// Only heap candidates are kept per key
+	for i, s := range ht.shards {
+		if len(s.keyFreqs) > topN {
+			t.Errorf("shard %d: expected at most %d tracked keys, got %d", i, topN, len(s.keyFreqs))
+		}
+	}
+}
+
+func TestCountMinSketch(t *testing.T) {
+	c := newCountMinSketch(64, 3)
+	if est := c.add(FNV1a("a"), 5); est != 5 {
+		t.Errorf("expected estimate 5, got %d", est)
+	}
+	if est := c.add(FNV1a("a"), 2); est != 7 {
+		t.Errorf("expected estimate 7, got %d", est)
+	}
+	if est := c.estimate(FNV1a("a")); est != 7 {
+		t.Errorf("expected estimate 7, got %d", est)
+	}
+	if c.total != 7 {
+		t.Errorf("expected total 7, got %d", c.total)
+	}
+}
+
+func TestWithSketchPanics(t *testing.T) {
+	for _, dims := range [][2]int{{0, 4}, {1024, 0}, {-1, 4}, {1024, -2}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected width %d and depth %d to panic", dims[0], dims[1])
+				}
+			}()
+			NewHotspotTracker(1, 1).WithSketch(dims[0], dims[1])
+		}()
+	}
+}
+
+func TestWithSketchIncompatibleModes(t *testing.T) {
+	tests := map[string]func() *HotspotTracker{
+		"window first":       func() *HotspotTracker { return NewHotspotTracker(1, 1).WithWindow(time.Minute).WithSketch(64, 4) },
+		"window after":       func() *HotspotTracker { return NewHotspotTracker(1, 1).WithSketch(64, 4).WithWindow(time.Minute) },
+		"decay after":        func() *HotspotTracker { return NewHotspotTracker(1, 1).WithSketch(64, 4).WithDecay(time.Minute) },
+		"lazy decay first":   func() *HotspotTracker { return NewHotspotTracker(1, 1).WithLazyDecay(time.Minute).WithSketch(64, 4) },
+		"observations first": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithMinObservations(2).WithSketch(64, 4) },
+		"observations after": func() *HotspotTracker { return NewHotspotTracker(1, 1).WithSketch(64, 4).WithMinObservations(2) },
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			build().Close()
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package htracker
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the format written by Snapshot. Restore
+// also reads version 1, which stored only each key's frequency, and rejects
+// any other version.
+const snapshotVersion = 2
+
+// ErrSnapshotVersion is returned by Restore for snapshots written in an
+// unsupported format version
+var ErrSnapshotVersion = errors.New("htracker: unsupported snapshot version")
+
+// ErrShardMismatch is returned by Restore when the snapshot was taken from
+// a tracker with a different number of shards
+var ErrShardMismatch = errors.New("htracker: snapshot shard count does not match")
+
+// snapshotHeader leads every snapshot
+type snapshotHeader struct {
+	Version       int
+	TopN          int
+	NumShards     int
+	TotalRequests int64
+
+	// ShardSalt is the salt keys were sharded with, which version 1
+	// snapshots predate and leave at 0
+	ShardSalt uint32
+}
+
+// snapshotEntry is a key and its frequency as stored in a snapshot
+type snapshotEntry[K comparable] struct {
+	Key       K
+	Frequency int
+	Weight    float64
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	// Observations is the key's request count under WithMinObservations
+	Observations int
+}
+
+// snapshotEntryV1 is a key and its frequency as stored in a version 1
+// snapshot
+type snapshotEntryV1[K comparable] struct {
+	Key       K
+	Frequency int
+}
+
+// migrate converts a version 1 entry to the current format. Version 1
+// trackers had no minimum observation count, so each count is taken to be
+// an observation, which keeps every restored hotspot eligible. Weights and
+// timestamps are left for load to fill in, as for any tracker that did not
+// record them.
+func (e snapshotEntryV1[K]) migrate() snapshotEntry[K] {
+	return snapshotEntry[K]{Key: e.Key, Frequency: e.Frequency, Observations: e.Frequency}
+}
+
+// PeekSnapshotVersion reads the header of a snapshot written by Snapshot
+// from r and returns its format version, for example to decide whether a
+// stored snapshot should be rewritten in the current format. It consumes r,
+// so the snapshot must be read again from the start to restore it.
+func PeekSnapshotVersion(r io.Reader) (int, error) {
+	var header snapshotHeader
+	if err := gob.NewDecoder(r).Decode(&header); err != nil {
+		return 0, fmt.Errorf("htracker: reading snapshot header: %w", err)
+	}
+	return header.Version, nil
+}
+
+// Snapshot writes the count of every key in every shard to w as a gob
+// stream, so that the tracker's state can be restored after a restart.
+// Shards are copied one at a time, so recording may continue meanwhile.
+func (ht *HotspotTrackerOf[K]) Snapshot(w io.Writer) error {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+
+	enc := gob.NewEncoder(w)
+	header := snapshotHeader{
+		Version:       snapshotVersion,
+		TopN:          ht.topN,
+		NumShards:     ht.numShards,
+		TotalRequests: ht.TotalRequests(),
+		ShardSalt:     ht.shardSalt,
+	}
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("htracker: writing snapshot header: %w", err)
+	}
+
+	for i, s := range ht.shards {
+		s.mu.RLock()
+		entries := make([]snapshotEntry[K], 0, len(s.keyFreqs))
+		for key, kf := range s.keyFreqs {
+			entries = append(entries, snapshotEntry[K]{
+				Key:          key,
+				Frequency:    loadFrequency(&kf.Frequency),
+				Weight:       kf.Weight,
+				FirstSeen:    kf.FirstSeen,
+				LastSeen:     kf.LastSeen,
+				Observations: s.observations[key],
+			})
+		}
+		s.mu.RUnlock()
+
+		if err := enc.Encode(entries); err != nil {
+			return fmt.Errorf("htracker: writing snapshot of shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Restore replaces the tracker's counts with a snapshot read from r. The
+// tracker must have as many shards as the one that took the snapshot, use
+// the same hash function and have been rebalanced by WithAutoRebalance as
+// many times, since keys are restored to the shard they were in. A
+// different topN is fine: each shard reselects its top N. The snapshot is
+// read in full before any state is replaced, so a failed Restore leaves the
+// tracker unchanged.
+func (ht *HotspotTrackerOf[K]) Restore(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("htracker: reading snapshot header: %w", err)
+	}
+	if header.Version != 1 && header.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, header.Version)
+	}
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	if header.NumShards != ht.numShards {
+		return fmt.Errorf("%w: snapshot has %d shards, tracker has %d", ErrShardMismatch, header.NumShards, ht.numShards)
+	}
+	if header.ShardSalt != ht.shardSalt {
+		return fmt.Errorf("%w: snapshot was rebalanced %d times, tracker %d", ErrShardMismatch, header.ShardSalt, ht.shardSalt)
+	}
+
+	shards := make([][]snapshotEntry[K], ht.numShards)
+	for i := range shards {
+		if err := decodeShard(dec, header.Version, &shards[i]); err != nil {
+			return fmt.Errorf("htracker: reading snapshot of shard %d: %w", i, err)
+		}
+	}
+
+	for i, s := range ht.shards {
+		s.mu.Lock()
+		s.load(shards[i])
+		s.mu.Unlock()
+	}
+	ht.totalRequests.Store(header.TotalRequests)
+	if ht.withCache {
+		ht.update.Store(true)
+	}
+	return nil
+}
+
+// decodeShard reads one shard's entries written in the given format
+// version into entries, migrating them to the current format
+func decodeShard[K comparable](dec *gob.Decoder, version int, entries *[]snapshotEntry[K]) error {
+	if version == snapshotVersion {
+		return dec.Decode(entries)
+	}
+
+	var old []snapshotEntryV1[K]
+	if err := dec.Decode(&old); err != nil {
+		return err
+	}
+	*entries = make([]snapshotEntry[K], len(old))
+	for i, e := range old {
+		(*entries)[i] = e.migrate()
+	}
+	return nil
+}
+
+// load replaces the shard's keys with entries. The caller must hold s.mu.
+func (s *shard[K]) load(entries []snapshotEntry[K]) {
+	s.keyFreqs = make(map[K]*KeyFreqOf[K], len(entries))
+	if s.window != nil {
+		for _, bucket := range s.window.buckets {
+			clear(bucket)
+		}
+	}
+	if s.weights != nil {
+		clear(s.weights)
+	}
+	if s.observations != nil {
+		clear(s.observations)
+	}
+
+	for _, e := range entries {
+		if s.universe != nil {
+			if _, tracked := s.universe[e.Key]; !tracked {
+				continue
+			}
+		}
+		kf := &KeyFreqOf[K]{Key: e.Key, Frequency: e.Frequency, Index: -1, FirstSeen: e.FirstSeen, LastSeen: e.LastSeen}
+		if s.timestamps && kf.LastSeen.IsZero() {
+			// Entries from trackers without timestamps count as seen now,
+			// rather than long idle
+			kf.FirstSeen = s.now()
+			kf.LastSeen = kf.FirstSeen
+		}
+		if s.floatWeights {
+			// Entries from integer trackers carry no weight
+			kf.Weight = e.Weight
+			if kf.Weight == 0 {
+				kf.Weight = float64(e.Frequency)
+			}
+		}
+		s.keyFreqs[e.Key] = kf
+		if s.window != nil {
+			s.window.buckets[s.window.current][e.Key] = e.Frequency
+		}
+		if s.weights != nil {
+			s.weights[e.Key] = float64(e.Frequency)
+		}
+		if s.observations != nil && e.Observations > 0 {
+			s.observations[e.Key] = e.Observations
+		}
+	}
+
+	s.keyCount.Store(int64(len(s.keyFreqs)))
+	s.rebuild()
+}
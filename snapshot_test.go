@@ -0,0 +1,157 @@
+package htracker
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		ht.RecordRequestN(key, (i+1)*10)
+	}
+	ht.RecordRequest("f")
+
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewHotspotTracker(3, 4)
+	restored.RecordRequestN("stale", 1000)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, expected := restored.GetHotspots(), ht.GetHotspots(); !slices.Equal(got, expected) {
+		t.Errorf("expected hotspots %v after restore, got %v", expected, got)
+	}
+	if freq, ok := restored.GetFrequency("f"); !ok || freq != 1 {
+		t.Errorf("expected non-hotspot 'f' to be restored as (1, true), got (%d, %v)", freq, ok)
+	}
+	if _, ok := restored.GetFrequency("stale"); ok {
+		t.Error("expected Restore to replace existing counts")
+	}
+	if restored.TotalRequests() != ht.TotalRequests() {
+		t.Errorf("expected %d total requests, got %d", ht.TotalRequests(), restored.TotalRequests())
+	}
+
+	// Recording continues from the restored counts
+	restored.RecordRequestN("a", 100)
+	if freq, _ := restored.GetFrequency("a"); freq != 110 {
+		t.Errorf("expected 'a' to have frequency 110, got %d", freq)
+	}
+}
+
+func TestRestoreShardMismatch(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	ht.RecordRequest("a")
+
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewHotspotTracker(3, 2)
+	restored.RecordRequest("b")
+	if err := restored.Restore(&buf); !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("expected ErrShardMismatch, got %v", err)
+	}
+	if !restored.IsHotspot("b") {
+		t.Error("expected a failed Restore to leave the tracker unchanged")
+	}
+}
+
+func TestRestoreTruncated(t *testing.T) {
+	ht := NewHotspotTracker(3, 4)
+	ht.RecordRequest("a")
+
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewHotspotTracker(3, 4)
+	restored.RecordRequest("b")
+	if err := restored.Restore(bytes.NewReader(buf.Bytes()[:buf.Len()-4])); err == nil {
+		t.Fatal("expected an error restoring a truncated snapshot")
+	}
+	if !restored.IsHotspot("b") {
+		t.Error("expected a failed Restore to leave the tracker unchanged")
+	}
+}
+
+// writeV1Snapshot writes counts in the version 1 format, which stored only
+// each key's frequency, sharded as ht would shard them
+func writeV1Snapshot(t *testing.T, ht *HotspotTracker, counts map[string]int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(snapshotHeader{Version: 1, TopN: ht.topN, NumShards: ht.numShards}); err != nil {
+		t.Fatal(err)
+	}
+	shards := make([][]snapshotEntryV1[string], ht.numShards)
+	for key, freq := range counts {
+		i := ht.shardIndex(key)
+		shards[i] = append(shards[i], snapshotEntryV1[string]{Key: key, Frequency: freq})
+	}
+	for _, entries := range shards {
+		if err := enc.Encode(entries); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRestoreV1Snapshot(t *testing.T) {
+	ht := NewHotspotTracker(2, 4).WithMinObservations(2)
+	blob := writeV1Snapshot(t, ht, map[string]int{"a": 9, "b": 5, "c": 1})
+
+	if version, err := PeekSnapshotVersion(bytes.NewReader(blob)); err != nil || version != 1 {
+		t.Fatalf("expected version 1, got (%d, %v)", version, err)
+	}
+	if err := ht.Restore(bytes.NewReader(blob)); err != nil {
+		t.Fatal(err)
+	}
+	// Migrated keys count as observed, so the minimum doesn't hide them
+	if got := ht.GetHotspots(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b] from the old snapshot, got %v", got)
+	}
+
+	// Snapshotting again writes the current format, which restores the same
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := PeekSnapshotVersion(bytes.NewReader(buf.Bytes())); err != nil || version != snapshotVersion {
+		t.Fatalf("expected version %d, got (%d, %v)", snapshotVersion, version, err)
+	}
+	restored := NewHotspotTracker(2, 4).WithMinObservations(2)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]int{"a": 9, "b": 5, "c": 1} {
+		if freq, _ := restored.GetFrequency(key); freq != expected {
+			t.Errorf("expected %q to round-trip at %d, got %d", key, expected, freq)
+		}
+	}
+	if got := restored.GetHotspots(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b] after the round trip, got %v", got)
+	}
+}
+
+func TestRestoreUnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snapshotHeader{Version: snapshotVersion + 1, NumShards: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHotspotTracker(1, 1).Restore(&buf); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("expected ErrSnapshotVersion, got %v", err)
+	}
+	if _, err := PeekSnapshotVersion(bytes.NewReader(nil)); err == nil {
+		t.Error("expected an error peeking at an empty snapshot")
+	}
+}
@@ -0,0 +1,174 @@
+package htracker
+
+import (
+	"math"
+	"sort"
+)
+
+// TotalRequests returns the number of requests recorded across all shards,
+// including requests for keys that never became hotspots
+func (ht *HotspotTrackerOf[K]) TotalRequests() int64 {
+	return ht.totalRequests.Load()
+}
+
+// TopKShare returns the fraction of all recorded requests accounted for by
+// the k most frequent hotspots. k is clamped to the number of tracked
+// hotspots, and 0 is returned when nothing has been recorded.
+func (ht *HotspotTrackerOf[K]) TopKShare(k int) float64 {
+	total := ht.TotalRequests()
+	if total == 0 || k <= 0 {
+		return 0
+	}
+
+	hotspots := ht.GetHotspotsWithCounts()
+	if k > len(hotspots) {
+		k = len(hotspots)
+	}
+	sum := 0
+	for _, kf := range hotspots[:k] {
+		sum += kf.Frequency
+	}
+	return float64(sum) / float64(total)
+}
+
+// HotspotShare returns the fraction of all recorded requests accounted for
+// by the current hotspots, or 0 when nothing has been recorded
+func (ht *HotspotTrackerOf[K]) HotspotShare() float64 {
+	return ht.TopKShare(ht.topN)
+}
+
+// HotspotsCoveringShare returns the fewest hottest keys, most frequent
+// first, whose frequencies add up to at least share of all recorded
+// requests, for example to size a cache that should absorb 80% of traffic.
+// Only the top N hotspots are considered, so if together they fall short
+// of share they are all returned. A share of 0 or less, or an empty
+// tracker, returns nil.
+func (ht *HotspotTrackerOf[K]) HotspotsCoveringShare(share float64) []KeyFreqOf[K] {
+	total := ht.TotalRequests()
+	if total == 0 || share <= 0 {
+		return nil
+	}
+
+	hotspots := ht.GetHotspotsWithCounts()
+	target := share * float64(total)
+	sum := 0
+	for i, kf := range hotspots {
+		sum += kf.Frequency
+		if float64(sum) >= target {
+			return hotspots[:i+1]
+		}
+	}
+	return hotspots
+}
+
+// HotspotTierOf holds the hotspots whose frequency is at least Min and below
+// the Min of the next hotter tier
+type HotspotTierOf[K comparable] struct {
+	Min  int
+	Keys []K
+}
+
+// HotspotTier is a HotspotTierOf string keys
+type HotspotTier = HotspotTierOf[string]
+
+// GetHotspotTiers partitions the current hotspots into frequency bands.
+// boundaries are the lower bounds of each band above the lowest one, so n
+// boundaries produce n+1 tiers returned hottest first. A key whose frequency
+// equals a boundary belongs to the tier that boundary starts. Keys within a
+// tier are ordered by descending frequency.
+func (ht *HotspotTrackerOf[K]) GetHotspotTiers(boundaries []int) []HotspotTierOf[K] {
+	bounds := append([]int(nil), boundaries...)
+	sort.Sort(sort.Reverse(sort.IntSlice(bounds)))
+
+	tiers := make([]HotspotTierOf[K], len(bounds)+1)
+	for i, bound := range bounds {
+		tiers[i].Min = bound
+	}
+
+	for _, kf := range ht.GetHotspotsWithCounts() {
+		i := sort.Search(len(bounds), func(i int) bool { return kf.Frequency >= bounds[i] })
+		tiers[i].Keys = append(tiers[i].Keys, kf.Key)
+	}
+	return tiers
+}
+
+// HotspotEstimateOf is a hotspot's frequency estimate with the bounds the true
+// frequency is expected to fall within
+type HotspotEstimateOf[K comparable] struct {
+	Key        K
+	Estimate   int
+	LowerBound int
+	UpperBound int
+}
+
+// HotspotEstimate is a HotspotEstimateOf a string key
+type HotspotEstimate = HotspotEstimateOf[string]
+
+// sampleZ is the standard normal quantile of the two-sided 95% interval
+// GetHotspotsWithCI reports for sampled counts
+const sampleZ = 1.96
+
+// GetHotspotsWithCI returns the current hotspots, most frequent first, with
+// an interval around each frequency. Exact counts carry no estimation error,
+// so both bounds equal the estimate. With WithSketch the estimate is an upper
+// bound and the lower bound is the sketch's error bound below it, which
+// holds with probability at least 1-e^-depth.
+//
+// With WithSampleRate every call is kept with probability p = 1/k and
+// counted k times, so a key with N requests has an estimate of k times a
+// Binomial(N, p) count, with variance N(k-1). Taking the estimate for N,
+// the interval is the estimate ± 1.96·sqrt(estimate·(k-1)), the normal
+// approximation's 95% interval, which assumes requests of weight 1 and is
+// loose for keys seen only a few times. The two errors add up when
+// sketching and sampling are combined.
+func (ht *HotspotTrackerOf[K]) GetHotspotsWithCI() []HotspotEstimateOf[K] {
+	hotspots := ht.GetHotspotsWithCounts()
+	estimates := make([]HotspotEstimateOf[K], len(hotspots))
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	for i, kf := range hotspots {
+		lower, upper := kf.Frequency, kf.Frequency
+		if s := ht.shards[ht.shardIndex(kf.Key)]; s.sketch != nil {
+			s.mu.RLock()
+			lower -= s.sketch.errorBound()
+			s.mu.RUnlock()
+		}
+		if ht.sampleEvery > 1 {
+			margin := int(math.Ceil(sampleZ * math.Sqrt(float64(kf.Frequency)*float64(ht.sampleEvery-1))))
+			lower -= margin
+			upper += margin
+		}
+		estimates[i] = HotspotEstimateOf[K]{
+			Key:        kf.Key,
+			Estimate:   kf.Frequency,
+			LowerBound: max(0, lower),
+			UpperBound: upper,
+		}
+	}
+	return estimates
+}
+
+// ShardStat describes the load on one shard
+type ShardStat struct {
+	// Index is the shard's position, as picked by the hash
+	Index int
+	// Keys is the number of keys the shard holds counts for
+	Keys int
+	// HeapSize is the number of those keys in the shard's top N
+	HeapSize int
+}
+
+// ShardStats reports how keys are spread over the shards, to spot a hash
+// that distributes them unevenly. Each shard is read under its own lock, so
+// the stats of different shards may be from slightly different moments.
+func (ht *HotspotTrackerOf[K]) ShardStats() []ShardStat {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	stats := make([]ShardStat, len(ht.shards))
+	for i, s := range ht.shards {
+		s.mu.RLock()
+		stats[i] = ShardStat{Index: i, Keys: len(s.keyFreqs), HeapSize: len(s.minHeap)}
+		s.mu.RUnlock()
+	}
+	return stats
+}
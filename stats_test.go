@@ -0,0 +1,217 @@
+package htracker
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestTopKShare(t *testing.T) {
+	ht := NewHotspotTracker(4, 2)
+
+	if share := ht.TopKShare(2); share != 0 {
+		t.Errorf("expected 0 share on empty tracker, got %f", share)
+	}
+
+	// 100 requests: a=40, b=30, c=20, d=5, e=5
+	counts := map[string]int{"a": 40, "b": 30, "c": 20, "d": 5, "e": 5}
+	for key, n := range counts {
+		for i := 0; i < n; i++ {
+			ht.RecordRequest(key)
+		}
+	}
+
+	if ht.TotalRequests() != 100 {
+		t.Fatalf("expected 100 total requests, got %d", ht.TotalRequests())
+	}
+
+	tests := []struct {
+		k        int
+		expected float64
+	}{
+		{0, 0},
+		{1, 0.40},
+		{2, 0.70},
+		{3, 0.90},
+		{4, 0.95},
+		{10, 0.95}, // clamped to the 4 tracked hotspots
+	}
+	for _, tt := range tests {
+		if share := ht.TopKShare(tt.k); math.Abs(share-tt.expected) > 1e-9 {
+			t.Errorf("TopKShare(%d): expected %f, got %f", tt.k, tt.expected, share)
+		}
+	}
+}
+
+func TestHotspotShare(t *testing.T) {
+	ht := NewHotspotTracker(5, 4)
+	if share := ht.HotspotShare(); share != 0 {
+		t.Errorf("expected 0 share on empty tracker, got %f", share)
+	}
+
+	// Zipf-like: key i gets 1000/i requests, so the top 5 carry about 44%
+	// of the traffic
+	var total, top int
+	for i := 1; i <= 100; i++ {
+		n := 1000 / i
+		ht.RecordRequestN(fmt.Sprintf("key%d", i), n)
+		total += n
+		if i <= 5 {
+			top += n
+		}
+	}
+
+	expected := float64(top) / float64(total)
+	if share := ht.HotspotShare(); math.Abs(share-expected) > 1e-9 {
+		t.Errorf("expected a share of %f, got %f", expected, share)
+	}
+}
+
+func TestHotspotsCoveringShare(t *testing.T) {
+	ht := NewHotspotTracker(50, 4)
+	if got := ht.HotspotsCoveringShare(0.8); got != nil {
+		t.Errorf("expected nil on empty tracker, got %v", got)
+	}
+
+	// Zipf-like: key i gets 1000/i requests
+	var total int
+	for i := 1; i <= 100; i++ {
+		n := 1000 / i
+		ht.RecordRequestN(fmt.Sprintf("key%d", i), n)
+		total += n
+	}
+
+	for _, share := range []float64{0.2, 0.5, 0.8} {
+		covering := ht.HotspotsCoveringShare(share)
+		sum := 0
+		for i, kf := range covering {
+			if want := fmt.Sprintf("key%d", i+1); kf.Key != want {
+				t.Fatalf("share %.1f: expected %s at position %d, got %s", share, want, i, kf.Key)
+			}
+			sum += kf.Frequency
+		}
+		last := covering[len(covering)-1].Frequency
+		if float64(sum) < share*float64(total) || float64(sum-last) >= share*float64(total) {
+			t.Errorf("share %.1f: %d keys cover %d of %d requests, expected just enough for the share",
+				share, len(covering), sum, total)
+		}
+	}
+
+	// The top N fall short of 99%, so all of them are returned
+	if got := ht.HotspotsCoveringShare(0.99); len(got) != 50 {
+		t.Errorf("expected all 50 hotspots, got %d", len(got))
+	}
+	if got := ht.HotspotsCoveringShare(0); got != nil {
+		t.Errorf("expected nil for a zero share, got %v", got)
+	}
+}
+
+func TestGetHotspotTiers(t *testing.T) {
+	ht := NewHotspotTracker(10, 2)
+
+	counts := map[string]int{"a": 120, "b": 100, "c": 50, "d": 10, "e": 9, "f": 1}
+	for key, n := range counts {
+		for i := 0; i < n; i++ {
+			ht.RecordRequest(key)
+		}
+	}
+
+	tiers := ht.GetHotspotTiers([]int{10, 100})
+	expected := []HotspotTier{
+		{Min: 100, Keys: []string{"a", "b"}},
+		{Min: 10, Keys: []string{"c", "d"}},
+		{Min: 0, Keys: []string{"e", "f"}},
+	}
+	if len(tiers) != len(expected) {
+		t.Fatalf("expected %d tiers, got %v", len(expected), tiers)
+	}
+	for i := range expected {
+		if tiers[i].Min != expected[i].Min {
+			t.Errorf("tier %d: expected min %d, got %d", i, expected[i].Min, tiers[i].Min)
+		}
+		if fmt.Sprint(tiers[i].Keys) != fmt.Sprint(expected[i].Keys) {
+			t.Errorf("tier %d: expected keys %v, got %v", i, expected[i].Keys, tiers[i].Keys)
+		}
+	}
+
+	// No boundaries yields a single tier with every hotspot
+	if tiers := ht.GetHotspotTiers(nil); len(tiers) != 1 || len(tiers[0].Keys) != 6 {
+		t.Errorf("expected one tier with all hotspots, got %v", tiers)
+	}
+}
+
+func TestGetHotspotsWithCIExact(t *testing.T) {
+	ht := NewHotspotTracker(3, 2)
+	for _, key := range []string{"a", "a", "a", "b", "b", "c"} {
+		ht.RecordRequest(key)
+	}
+
+	expected := []HotspotEstimate{
+		{Key: "a", Estimate: 3, LowerBound: 3, UpperBound: 3},
+		{Key: "b", Estimate: 2, LowerBound: 2, UpperBound: 2},
+		{Key: "c", Estimate: 1, LowerBound: 1, UpperBound: 1},
+	}
+	actual := ht.GetHotspotsWithCI()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("position %d: expected %+v, got %+v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestGetHotspotsWithCISampled(t *testing.T) {
+	// Key i gets 200*i requests; count how often its interval holds it
+	const keys, trials = 10, 20
+	covered, total := 0, 0
+	for trial := 0; trial < trials; trial++ {
+		ht := NewHotspotTracker(keys, 2).WithSampleRate(0.1).WithRandSource(rand.NewPCG(uint64(trial), 7))
+		for i := 1; i <= keys; i++ {
+			for j := 0; j < 200*i; j++ {
+				ht.RecordRequest(fmt.Sprintf("key%d", i))
+			}
+		}
+
+		for _, est := range ht.GetHotspotsWithCI() {
+			if est.LowerBound > est.Estimate || est.UpperBound < est.Estimate || est.LowerBound == est.UpperBound {
+				t.Fatalf("expected a proper interval around the estimate, got %+v", est)
+			}
+			var i int
+			fmt.Sscanf(est.Key, "key%d", &i)
+			if est.LowerBound <= 200*i && 200*i <= est.UpperBound {
+				covered++
+			}
+			total++
+		}
+	}
+	// The intervals claim 95%; allow for the trials' own variance
+	if rate := float64(covered) / float64(total); rate < 0.9 {
+		t.Errorf("expected about 95%% of intervals to hold the true count, got %.2f", rate)
+	}
+}
+
+func TestShardStats(t *testing.T) {
+	// Send every key to the shard named by its first letter
+	ht := NewHotspotTracker(2, 3).WithHashFunc(func(key string) uint32 { return uint32(key[0] - 'a') })
+	for _, key := range []string{"a1", "a2", "a3", "a4", "a5", "b1", "b2", "a1", "b1"} {
+		ht.RecordRequest(key)
+	}
+
+	expected := []ShardStat{
+		{Index: 0, Keys: 5, HeapSize: 2},
+		{Index: 1, Keys: 2, HeapSize: 2},
+		{Index: 2, Keys: 0, HeapSize: 0},
+	}
+	stats := ht.ShardStats()
+	if len(stats) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, stats)
+	}
+	for i := range expected {
+		if stats[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], stats[i])
+		}
+	}
+}
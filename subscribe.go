@@ -0,0 +1,93 @@
+package htracker
+
+import "sync"
+
+// subscriberBuffer is the number of events a subscriber may fall behind by
+// before further events are dropped
+const subscriberBuffer = 64
+
+// HotspotEventOf reports a key entering or leaving the hotspots
+type HotspotEventOf[K comparable] struct {
+	Key K
+	// Frequency is the key's aggregated frequency when it entered, or its
+	// last aggregated frequency as a hotspot when it left
+	Frequency int
+	Entered   bool
+}
+
+// HotspotEvent reports a string key entering or leaving the hotspots
+type HotspotEvent = HotspotEventOf[string]
+
+// subscribers tracks the hotspot set between aggregations for Subscribe
+type subscribers[K comparable] struct {
+	mu    sync.Mutex
+	chans map[chan HotspotEventOf[K]]struct{}
+	last  map[K]int
+}
+
+// Subscribe returns a channel receiving an event whenever a key enters or
+// leaves the hotspots, and a function that unsubscribes and closes the
+// channel. Changes are found by comparing consecutive aggregations, which
+// with caching enabled happen on cache rebuilds, so the first aggregation
+// after the first subscription reports every hotspot as entered. Events are
+// sent without blocking: a subscriber more than 64 events behind misses
+// events rather than stalling the tracker.
+func (ht *HotspotTrackerOf[K]) Subscribe() (<-chan HotspotEventOf[K], func()) {
+	ch := make(chan HotspotEventOf[K], subscriberBuffer)
+
+	subs := &ht.subscribers
+	subs.mu.Lock()
+	if subs.chans == nil {
+		subs.chans = make(map[chan HotspotEventOf[K]]struct{})
+	}
+	subs.chans[ch] = struct{}{}
+	subs.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subs.mu.Lock()
+			defer subs.mu.Unlock()
+			delete(subs.chans, ch)
+			close(ch)
+			if len(subs.chans) == 0 {
+				subs.last = nil
+			}
+		})
+	}
+}
+
+// publish sends subscribers the difference between the hotspots of the
+// previous aggregation and those of aggregate
+func (subs *subscribers[K]) publish(aggregate *shard[K]) {
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	if len(subs.chans) == 0 {
+		return
+	}
+
+	current := make(map[K]int, len(aggregate.minHeap))
+	// Walk the aggregate most frequent first so events come in rank order
+	for i := len(aggregate.minHeap) - 1; i >= 0; i-- {
+		kf := aggregate.minHeap[i]
+		current[kf.Key] = kf.Frequency
+		if _, ok := subs.last[kf.Key]; !ok {
+			subs.send(HotspotEventOf[K]{Key: kf.Key, Frequency: kf.Frequency, Entered: true})
+		}
+	}
+	for key, freq := range subs.last {
+		if _, ok := current[key]; !ok {
+			subs.send(HotspotEventOf[K]{Key: key, Frequency: freq})
+		}
+	}
+	subs.last = current
+}
+
+func (subs *subscribers[K]) send(event HotspotEventOf[K]) {
+	for ch := range subs.chans {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package htracker
+
+import "testing"
+
+// drain returns the events buffered in ch
+func drain(ch <-chan HotspotEvent) []HotspotEvent {
+	var events []HotspotEvent
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ht := NewHotspotTracker(2, 2)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+
+	events, unsubscribe := ht.Subscribe()
+
+	ht.GetHotspots()
+	got := drain(events)
+	expected := []HotspotEvent{{Key: "a", Frequency: 5, Entered: true}, {Key: "b", Frequency: 3, Entered: true}}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// An unchanged hotspot set produces no events
+	ht.RecordRequest("a")
+	ht.GetHotspots()
+	if got := drain(events); len(got) != 0 {
+		t.Errorf("expected no events, got %v", got)
+	}
+
+	ht.RecordRequestN("c", 4)
+	ht.GetHotspots()
+	got = drain(events)
+	expected = []HotspotEvent{{Key: "c", Frequency: 4, Entered: true}, {Key: "b", Frequency: 3}}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("expected the channel to be closed after unsubscribing")
+	}
+	ht.RecordRequestN("d", 10)
+	ht.GetHotspots()
+}
+
+func TestSubscribeSlowConsumer(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	events, unsubscribe := ht.Subscribe()
+	defer unsubscribe()
+
+	// Each aggregation swaps the single hotspot, producing two events, and
+	// nobody reads them
+	for i := 0; i < subscriberBuffer; i++ {
+		ht.RecordRequestN(string(rune('a'+i%26))+string(rune('a'+i/26)), i+1)
+		ht.GetHotspots()
+	}
+	if got := len(drain(events)); got != subscriberBuffer {
+		t.Errorf("expected the buffer to fill up with %d events, got %d", subscriberBuffer, got)
+	}
+}
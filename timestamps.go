@@ -0,0 +1,41 @@
+package htracker
+
+import "time"
+
+// KeyInfo describes a tracked key
+type KeyInfo struct {
+	Frequency int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// WithKeyTimestamps records when every key was first and last recorded,
+// read from the tracker's clock, for GetKeyInfo. WithKeyTTL enables it
+// too. Reading the clock takes every request off the read-locked fast
+// path. It must be called before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithKeyTimestamps() *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.timestamps = true
+		s.now = ht.clock.Now
+	}
+	return ht
+}
+
+// GetKeyInfo returns key's frequency and, under WithKeyTimestamps or
+// WithKeyTTL, when it was first and last recorded, and whether it is
+// tracked. A key that is removed, pruned or expired starts over.
+func (ht *HotspotTrackerOf[K]) GetKeyInfo(key K) (KeyInfo, bool) {
+	key = ht.normalizeKey(key)
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	s := ht.shards[ht.shardIndex(key)]
+	s.expire()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kf, exists := s.keyFreqs[key]
+	if !exists {
+		return KeyInfo{}, false
+	}
+	return KeyInfo{Frequency: loadFrequency(&kf.Frequency), FirstSeen: kf.FirstSeen, LastSeen: kf.LastSeen}, true
+}
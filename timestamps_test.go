@@ -0,0 +1,68 @@
+package htracker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestGetKeyInfo(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := &fakeClock{now: start}
+	ht := NewHotspotTracker(2, 2).WithClock(clock).WithKeyTimestamps()
+
+	ht.RecordRequest("a")
+	clock.Advance(10 * time.Second)
+	ht.RecordRequestN("a", 3)
+	ht.RecordRequest("b")
+	clock.Advance(5 * time.Second)
+	ht.RecordRequest("a")
+
+	info, ok := ht.GetKeyInfo("a")
+	if !ok {
+		t.Fatal("expected 'a' to be tracked")
+	}
+	want := KeyInfo{Frequency: 5, FirstSeen: start, LastSeen: start.Add(15 * time.Second)}
+	if !sameKeyInfo(info, want) {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+	if info, _ := ht.GetKeyInfo("b"); !info.FirstSeen.Equal(start.Add(10*time.Second)) || !info.LastSeen.Equal(info.FirstSeen) {
+		t.Errorf("expected 'b' to be first and last seen at 10s, got %+v", info)
+	}
+	if _, ok := ht.GetKeyInfo("missing"); ok {
+		t.Error("expected an unknown key not to be tracked")
+	}
+
+	// Timestamps survive a resize and a snapshot
+	if err := ht.Resize(4); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := ht.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewHotspotTracker(2, 4)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, tracker := range []*HotspotTracker{ht, restored} {
+		if info, _ := tracker.GetKeyInfo("a"); !sameKeyInfo(info, want) {
+			t.Errorf("expected %+v, got %+v", want, info)
+		}
+	}
+}
+
+func TestGetKeyInfoWithoutTimestamps(t *testing.T) {
+	ht := NewHotspotTracker(2, 1)
+	ht.RecordRequest("a")
+	info, ok := ht.GetKeyInfo("a")
+	if !ok || info.Frequency != 1 || !info.FirstSeen.IsZero() || !info.LastSeen.IsZero() {
+		t.Errorf("expected frequency 1 and no timestamps, got %+v", info)
+	}
+}
+
+// sameKeyInfo compares a and b with time.Time.Equal, since a snapshot may
+// change a time's location
+func sameKeyInfo(a, b KeyInfo) bool {
+	return a.Frequency == b.Frequency && a.FirstSeen.Equal(b.FirstSeen) && a.LastSeen.Equal(b.LastSeen)
+}
@@ -0,0 +1,29 @@
+package htracker
+
+// TrackerOf is the part of a tracker that code recording requests and
+// reading hotspots typically needs, so that such code can depend on it
+// rather than on *HotspotTrackerOf and be tested with a fake. Trackers are
+// still built with New or NewHotspotTrackerOf and configured, and closed,
+// through the concrete type.
+type TrackerOf[K comparable] interface {
+	// RecordRequest records a request for key
+	RecordRequest(key K)
+	// RecordRequestN records a request for key weighted by n
+	RecordRequestN(key K, n int)
+	// GetHotspots returns the current hotspots, most frequent first
+	GetHotspots() []K
+	// GetHotspotsWithCounts returns the current hotspots with their
+	// frequencies, most frequent first
+	GetHotspotsWithCounts() []KeyFreqOf[K]
+	// IsHotspot reports whether key is a current hotspot
+	IsHotspot(key K) bool
+	// GetFrequency returns key's frequency and whether it is tracked
+	GetFrequency(key K) (int, bool)
+	// TotalRequests returns the number of requests recorded
+	TotalRequests() int64
+}
+
+// Tracker is a TrackerOf for string keys, implemented by *HotspotTracker
+type Tracker = TrackerOf[string]
+
+var _ Tracker = (*HotspotTracker)(nil)
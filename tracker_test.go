@@ -0,0 +1,74 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+)
+
+// fakeTracker is a Tracker that reports a fixed set of hotspots and
+// remembers what was recorded
+type fakeTracker struct {
+	hot      []string
+	recorded map[string]int
+}
+
+func (f *fakeTracker) RecordRequest(key string) { f.RecordRequestN(key, 1) }
+
+func (f *fakeTracker) RecordRequestN(key string, n int) {
+	if f.recorded == nil {
+		f.recorded = make(map[string]int)
+	}
+	f.recorded[key] += n
+}
+
+func (f *fakeTracker) GetHotspots() []string { return f.hot }
+
+func (f *fakeTracker) GetHotspotsWithCounts() []KeyFreq {
+	kfs := make([]KeyFreq, len(f.hot))
+	for i, key := range f.hot {
+		kfs[i] = KeyFreq{Key: key, Frequency: f.recorded[key]}
+	}
+	return kfs
+}
+
+func (f *fakeTracker) IsHotspot(key string) bool { return slices.Contains(f.hot, key) }
+
+func (f *fakeTracker) GetFrequency(key string) (int, bool) {
+	freq, ok := f.recorded[key]
+	return freq, ok
+}
+
+func (f *fakeTracker) TotalRequests() int64 {
+	var total int64
+	for _, n := range f.recorded {
+		total += int64(n)
+	}
+	return total
+}
+
+// serveKey is typical consumer code: it records every request and reports
+// whether the key should be served from a hot-key cache
+func serveKey(t Tracker, key string) bool {
+	t.RecordRequest(key)
+	return t.IsHotspot(key)
+}
+
+func TestTrackerInterface(t *testing.T) {
+	fake := &fakeTracker{hot: []string{"hot"}}
+	if !serveKey(fake, "hot") || serveKey(fake, "cold") {
+		t.Error("expected only 'hot' to be served as a hotspot by the fake")
+	}
+	if fake.TotalRequests() != 2 {
+		t.Errorf("expected the fake to see 2 requests, got %d", fake.TotalRequests())
+	}
+
+	// The real tracker is a drop-in replacement
+	var real Tracker = NewHotspotTracker(1, 1)
+	serveKey(real, "a")
+	if !serveKey(real, "a") {
+		t.Error("expected 'a' to be a hotspot of the real tracker")
+	}
+	if freq, _ := real.GetFrequency("a"); freq != 2 {
+		t.Errorf("expected 'a' to have frequency 2, got %d", freq)
+	}
+}
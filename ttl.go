@@ -0,0 +1,35 @@
+package htracker
+
+import "time"
+
+// WithKeyTTL drops keys that have not been recorded for d, however frequent
+// they were, removing them from both the counts and the heap. Idle keys are
+// swept every d/10 from the tracker's ticker, which Close stops, so a key is
+// dropped between d and 1.1*d after it was last recorded. It must be called
+// before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithKeyTTL(d time.Duration) *HotspotTrackerOf[K] {
+	ht.WithKeyTimestamps()
+	ht.keyTTL = d
+	return ht.withPeriodicTask(d/10, func(s *shard[K]) {
+		s.expireIdle(ht.clock.Now().Add(-ht.keyTTL))
+	})
+}
+
+// expireIdle removes every key last seen before cutoff, reporting whether
+// any was removed. Keys outside the heap may take the freed slots.
+func (s *shard[K]) expireIdle(cutoff time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := false
+	for key, kf := range s.keyFreqs {
+		if kf.LastSeen.Before(cutoff) {
+			s.removeLocked(key)
+			removed = true
+		}
+	}
+	if removed {
+		s.rebuild()
+	}
+	return removed
+}
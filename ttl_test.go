@@ -0,0 +1,48 @@
+package htracker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := NewHotspotTracker(2, 2)
+	ht.WithClock(clock).WithKeyTTL(time.Minute).WithCache(time.Hour)
+	defer ht.Close()
+
+	ht.RecordRequestN("spike", 100)
+	ht.RecordRequestN("steady", 2)
+	ht.RecordRequest("cold")
+	ht.GetHotspots() // prime the cache
+
+	// "steady" keeps being recorded every 18s while "spike" goes idle,
+	// swept every 6s
+	tick := clock.ticker(ht, 6*time.Second)
+	for i := 1; i <= 12; i++ {
+		tick()
+		if i%3 == 0 {
+			ht.RecordRequest("steady")
+		}
+	}
+
+	if _, ok := ht.GetFrequency("spike"); ok {
+		t.Error("expected idle 'spike' to be dropped")
+	}
+	if _, ok := ht.GetFrequency("cold"); ok {
+		t.Error("expected idle 'cold' to be dropped")
+	}
+	hotspots := ht.GetHotspots()
+	if len(hotspots) != 1 || hotspots[0] != "steady" {
+		t.Errorf("expected ['steady'] after the cache was invalidated, got %v", hotspots)
+	}
+	if freq, _ := ht.GetFrequency("steady"); freq != 6 {
+		t.Errorf("expected 'steady' to keep frequency 6, got %d", freq)
+	}
+
+	// A dropped key starts over when it returns
+	ht.RecordRequest("spike")
+	if freq, _ := ht.GetFrequency("spike"); freq != 1 {
+		t.Errorf("expected 'spike' to restart at 1, got %d", freq)
+	}
+}
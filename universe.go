@@ -0,0 +1,55 @@
+package htracker
+
+// SetTrackedKeys restricts the tracker to a fixed universe of keys, for
+// trackers whose key set comes from configuration. Requests for other keys
+// are ignored, and counted in Events as ReasonUntracked. Keys that stay in
+// the universe keep their counts, keys that leave it are dropped, and keys
+// that join it start from zero. Passing nil lifts the restriction.
+//
+// All shards are switched while holding every shard lock, so concurrent
+// recording observes either the old or the new universe, never a mix.
+func (ht *HotspotTrackerOf[K]) SetTrackedKeys(keys []K) {
+	var universe map[K]struct{}
+	if keys != nil {
+		universe = make(map[K]struct{}, len(keys))
+		for _, key := range keys {
+			universe[ht.normalizeKey(key)] = struct{}{}
+		}
+	}
+
+	ht.resizeMu.RLock()
+	for _, s := range ht.shards {
+		s.mu.Lock()
+	}
+	for _, s := range ht.shards {
+		s.restrict(universe)
+	}
+	for _, s := range ht.shards {
+		s.mu.Unlock()
+	}
+	ht.resizeMu.RUnlock()
+
+	if ht.withCache {
+		ht.update.Store(true)
+	}
+}
+
+// restrict drops every key outside universe, letting the remaining keys
+// take the freed heap slots. The caller must hold s.mu.
+func (s *shard[K]) restrict(universe map[K]struct{}) {
+	s.universe = universe
+	if universe == nil {
+		return
+	}
+
+	removed := false
+	for key := range s.keyFreqs {
+		if _, tracked := universe[key]; !tracked {
+			s.removeLocked(key)
+			removed = true
+		}
+	}
+	if removed {
+		s.rebuild()
+	}
+}
@@ -0,0 +1,97 @@
+package htracker
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetTrackedKeys(t *testing.T) {
+	ht := NewHotspotTracker(5, 2)
+	ht.SetTrackedKeys([]string{"a", "b", "c"})
+
+	for _, key := range []string{"a", "a", "a", "b", "b", "c", "x", "x", "x", "x"} {
+		ht.RecordRequest(key)
+	}
+	if _, ok := ht.GetFrequency("x"); ok {
+		t.Error("expected untracked key 'x' to be ignored")
+	}
+
+	ht.SetTrackedKeys([]string{"a", "b", "d"})
+
+	if freq, _ := ht.GetFrequency("a"); freq != 3 {
+		t.Errorf("expected retained key 'a' to keep frequency 3, got %d", freq)
+	}
+	if freq, _ := ht.GetFrequency("b"); freq != 2 {
+		t.Errorf("expected retained key 'b' to keep frequency 2, got %d", freq)
+	}
+	if _, ok := ht.GetFrequency("c"); ok {
+		t.Error("expected removed key 'c' to be dropped")
+	}
+	if ht.IsHotspot("c") {
+		t.Error("expected removed key 'c' not to be a hotspot")
+	}
+	if freq, _ := ht.GetFrequency("d"); freq != 0 {
+		t.Errorf("expected new key 'd' to start at zero, got %d", freq)
+	}
+
+	ht.RecordRequest("d")
+	ht.RecordRequest("c")
+	if freq, _ := ht.GetFrequency("d"); freq != 1 {
+		t.Errorf("expected new key 'd' to count from zero, got %d", freq)
+	}
+	if _, ok := ht.GetFrequency("c"); ok {
+		t.Error("expected removed key 'c' to stay ignored")
+	}
+
+	// Lifting the restriction tracks everything again
+	ht.SetTrackedKeys(nil)
+	ht.RecordRequest("x")
+	if freq, _ := ht.GetFrequency("x"); freq != 1 {
+		t.Errorf("expected 'x' to be tracked once unrestricted, got %d", freq)
+	}
+}
+
+func TestSetTrackedKeysPromotes(t *testing.T) {
+	ht := NewHotspotTracker(1, 1)
+	ht.RecordRequestN("a", 5)
+	ht.RecordRequestN("b", 3)
+
+	ht.SetTrackedKeys([]string{"b"})
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "b" {
+		t.Errorf("expected 'b' to take the freed slot, got %v", hotspots)
+	}
+}
+
+// TestSetTrackedKeysConcurrent swaps the universe while recording. Run it with -race.
+func TestSetTrackedKeysConcurrent(t *testing.T) {
+	ht := NewHotspotTracker(10, 4)
+	ht.SetTrackedKeys([]string{"a", "b"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ht.RecordRequest("a")
+				ht.RecordRequest("b")
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if i%2 == 0 {
+			ht.SetTrackedKeys([]string{"a"})
+		} else {
+			ht.SetTrackedKeys([]string{"a", "b"})
+		}
+	}
+	ht.SetTrackedKeys([]string{"a"})
+	wg.Wait()
+
+	if freq, _ := ht.GetFrequency("a"); freq != 4000 {
+		t.Errorf("expected 'a' to be counted throughout, got %d", freq)
+	}
+	if _, ok := ht.GetFrequency("b"); ok {
+		t.Error("expected 'b' to be dropped by the final universe")
+	}
+}
@@ -0,0 +1,58 @@
+package htracker
+
+import "slices"
+
+// SnapshotOf is a read-only copy of a tracker's hotspots at one instant.
+// It shares nothing with the tracker, so recording afterwards doesn't
+// change it, and nothing it returns can change it.
+type SnapshotOf[K comparable] struct {
+	hotspots []KeyFreqOf[K] // most frequent first
+	freqs    map[K]int
+}
+
+// Snapshot is a read-only copy of a HotspotTracker's hotspots
+type Snapshot = SnapshotOf[string]
+
+// AggregateData returns a read-only snapshot of the current hotspots
+// across all shards. It reads the aggregate like GetHotspots, so it
+// respects the cache.
+func (ht *HotspotTrackerOf[K]) AggregateData() *SnapshotOf[K] {
+	aggregateShard := ht.aggregate()
+	defer ht.releaseAggregate(aggregateShard)
+
+	hotspots := aggregateShard.sortedKeyFreqs()
+	freqs := make(map[K]int, len(hotspots))
+	for i := range hotspots {
+		hotspots[i].Index = -1
+		freqs[hotspots[i].Key] = hotspots[i].Frequency
+	}
+	return &SnapshotOf[K]{hotspots: hotspots, freqs: freqs}
+}
+
+// GetHotspots returns the snapshot's hotspots, most frequent first
+func (s *SnapshotOf[K]) GetHotspots() []K {
+	keys := make([]K, len(s.hotspots))
+	for i, kf := range s.hotspots {
+		keys[i] = kf.Key
+	}
+	return keys
+}
+
+// GetHotspotsWithCounts returns the snapshot's hotspots with their
+// frequencies, most frequent first
+func (s *SnapshotOf[K]) GetHotspotsWithCounts() []KeyFreqOf[K] {
+	return slices.Clone(s.hotspots)
+}
+
+// IsHotspot reports whether key was a hotspot
+func (s *SnapshotOf[K]) IsHotspot(key K) bool {
+	_, ok := s.freqs[key]
+	return ok
+}
+
+// GetFrequency returns key's frequency and true if it was a hotspot. The
+// snapshot holds only the hotspots, so other keys report false.
+func (s *SnapshotOf[K]) GetFrequency(key K) (int, bool) {
+	freq, ok := s.freqs[key]
+	return freq, ok
+}
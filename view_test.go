@@ -0,0 +1,46 @@
+package htracker
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAggregateDataSnapshot(t *testing.T) {
+	for _, cached := range []bool{false, true} {
+		ht := NewHotspotTracker(2, 4)
+		if cached {
+			ht.WithCache(time.Hour)
+		}
+		ht.RecordRequestN("a", 5)
+		ht.RecordRequestN("b", 3)
+		ht.RecordRequest("c")
+
+		snap := ht.AggregateData()
+		check := func(when string) {
+			t.Helper()
+			if got := snap.GetHotspots(); !slices.Equal(got, []string{"a", "b"}) {
+				t.Errorf("cached=%v %s: expected [a b], got %v", cached, when, got)
+			}
+			if freq, ok := snap.GetFrequency("a"); !ok || freq != 5 {
+				t.Errorf("cached=%v %s: expected a at 5, got %d, %v", cached, when, freq, ok)
+			}
+			if !snap.IsHotspot("b") || snap.IsHotspot("c") {
+				t.Errorf("cached=%v %s: expected b and not c to be hotspots", cached, when)
+			}
+		}
+		check("at first")
+
+		// Mutating what the snapshot returned doesn't reach it
+		snap.GetHotspots()[0] = "z"
+		snap.GetHotspotsWithCounts()[0].Frequency = 100
+
+		// Nor does recording into or resetting the tracker
+		ht.RecordRequestN("c", 10)
+		ht.RemoveKey("a")
+		ht.update.Store(true)
+		ht.GetHotspots()
+		check("after mutation")
+		ht.Close()
+	}
+}
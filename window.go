@@ -0,0 +1,156 @@
+package htracker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// windowBuckets is the number of buckets a sliding window is divided into
+const windowBuckets = 60
+
+// WithWindow switches the tracker to a sliding time window, so that only
+// requests recorded within the last d count towards a key's frequency. The
+// window is divided into buckets of d/60 which expire as a whole, so a
+// request ages out between d-d/60 and d after it was recorded. It must be
+// called before any request is recorded.
+func (ht *HotspotTrackerOf[K]) WithWindow(d time.Duration) *HotspotTrackerOf[K] {
+	for _, s := range ht.shards {
+		s.window = newWindow[K](d, ht.clock.Now)
+	}
+	ht.checkFloatWeights()
+	ht.checkSketch()
+	return ht
+}
+
+// window keeps per-bucket request counts for a shard's keys. buckets is a
+// ring where current holds the requests recorded since start.
+type window[K comparable] struct {
+	width   time.Duration
+	now     func() time.Time
+	buckets []map[K]int
+	current int
+	start   time.Time
+}
+
+func newWindow[K comparable](d time.Duration, now func() time.Time) *window[K] {
+	width := d / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	buckets := make([]map[K]int, windowBuckets)
+	for i := range buckets {
+		buckets[i] = make(map[K]int)
+	}
+	return &window[K]{width: width, now: now, buckets: buckets, start: now()}
+}
+
+// forget drops key from every bucket
+func (w *window[K]) forget(key K) {
+	for _, bucket := range w.buckets {
+		delete(bucket, key)
+	}
+}
+
+// expire ages out the buckets that have left the window and applies any
+// lazy decay the shard owes
+func (s *shard[K]) expire() {
+	if s.window == nil && s.lazyHalfLife == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window != nil {
+		s.expireLocked()
+	}
+	if s.lazyHalfLife > 0 {
+		s.catchUpLocked()
+	}
+}
+
+// expireLocked subtracts the counts of every bucket that has left the window
+// and reselects the top N, since an expiry can reorder keys inside and
+// outside the heap. The caller must hold s.mu.
+func (s *shard[K]) expireLocked() {
+	w := s.window
+	elapsed := int(w.now().Sub(w.start) / w.width)
+	if elapsed <= 0 {
+		return
+	}
+	w.start = w.start.Add(time.Duration(elapsed) * w.width)
+	if elapsed > len(w.buckets) {
+		elapsed = len(w.buckets)
+	}
+
+	for i := 0; i < elapsed; i++ {
+		w.current = (w.current + 1) % len(w.buckets)
+		for key, n := range w.buckets[w.current] {
+			kf, exists := s.keyFreqs[key]
+			if !exists {
+				continue
+			}
+			kf.Frequency -= n
+			if kf.Frequency <= 0 {
+				s.removeLocked(key)
+			}
+		}
+		clear(w.buckets[w.current])
+	}
+	s.rebuild()
+}
+
+// ErrWindowDisabled is returned by GetHotspotsAt when WithWindow was not
+// called
+var ErrWindowDisabled = errors.New("htracker: window is not enabled")
+
+// ErrBucketOutOfRange is returned by GetHotspotsAt for a bucket that is not
+// retained
+var ErrBucketOutOfRange = errors.New("htracker: bucket is outside the window")
+
+// GetHotspotsAt returns the top N keys counted over a single bucket of the
+// window, most frequent first, where bucketsAgo 0 is the bucket currently
+// being recorded into and windowBuckets-1 the oldest one retained. Buckets
+// are d/60 wide for a window of d, so with a one hour window
+// GetHotspotsAt(5) answers what was hot five to six minutes ago.
+// WithMinFrequency and WithAdaptiveThreshold are not applied.
+func (ht *HotspotTrackerOf[K]) GetHotspotsAt(bucketsAgo int) ([]KeyFreqOf[K], error) {
+	ht.resizeMu.RLock()
+	defer ht.resizeMu.RUnlock()
+	if ht.shards[0].window == nil {
+		return nil, ErrWindowDisabled
+	}
+	if bucketsAgo < 0 || bucketsAgo >= windowBuckets {
+		return nil, fmt.Errorf("%w: %d buckets ago, %d are retained", ErrBucketOutOfRange, bucketsAgo, windowBuckets)
+	}
+
+	var all []*KeyFreqOf[K]
+	for _, s := range ht.shards {
+		all = s.appendBucket(all, bucketsAgo)
+	}
+	slices.SortFunc(all, compareRank[K])
+	hotspots := make([]KeyFreqOf[K], 0, min(len(all), ht.topN))
+	for _, kf := range all[:min(len(all), ht.topN)] {
+		hotspots = append(hotspots, *kf)
+	}
+	return hotspots, nil
+}
+
+// appendBucket appends the counts of the bucket bucketsAgo buckets back to
+// kfs, after ageing out the buckets that have left the window
+func (s *shard[K]) appendBucket(kfs []*KeyFreqOf[K], bucketsAgo int) []*KeyFreqOf[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked()
+	for key, n := range s.window.bucket(bucketsAgo) {
+		kfs = append(kfs, &KeyFreqOf[K]{Key: key, Frequency: n, Index: -1})
+	}
+	return kfs
+}
+
+// bucket returns the bucket bucketsAgo buckets before the current one
+func (w *window[K]) bucket(bucketsAgo int) map[K]int {
+	return w.buckets[(w.current-bucketsAgo+len(w.buckets))%len(w.buckets)]
+}
@@ -0,0 +1,120 @@
+package htracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newWindowedTracker(clock *fakeClock, topN, numShards int, d time.Duration) *HotspotTracker {
+	return NewHotspotTracker(topN, numShards).WithClock(clock).WithWindow(d)
+}
+
+func TestWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := newWindowedTracker(clock, 2, 2, time.Minute)
+
+	ht.RecordRequestN("a", 5)
+	clock.Advance(30 * time.Second)
+	ht.RecordRequestN("b", 3)
+	ht.RecordRequest("a")
+
+	if freq, _ := ht.GetFrequency("a"); freq != 6 {
+		t.Errorf("expected 'a' to have frequency 6 within the window, got %d", freq)
+	}
+
+	// The first five requests for "a" age out, the rest are still in the window
+	clock.Advance(45 * time.Second)
+	if freq, _ := ht.GetFrequency("a"); freq != 1 {
+		t.Errorf("expected 'a' to have frequency 1 after its burst aged out, got %d", freq)
+	}
+	expected := []KeyFreq{{Key: "b", Frequency: 3}, {Key: "a", Frequency: 1}}
+	assertKeyFreqs(t, ht.GetHotspotsWithCounts(), expected)
+
+	// Everything ages out
+	clock.Advance(time.Minute)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 0 {
+		t.Errorf("expected no hotspots once the window has passed, got %v", hotspots)
+	}
+	if _, ok := ht.GetFrequency("b"); ok {
+		t.Error("expected 'b' to be dropped once its requests aged out")
+	}
+}
+
+// TestWindowReadmits checks that a key outside the heap takes the place of
+// a hotspot whose requests aged out, without being recorded again.
+func TestWindowReadmits(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := newWindowedTracker(clock, 1, 1, time.Minute)
+
+	ht.RecordRequestN("old", 10)
+	clock.Advance(30 * time.Second)
+	ht.RecordRequestN("new", 4)
+	if !ht.IsHotspot("old") {
+		t.Fatalf("expected 'old' to be the hotspot, got %v", ht.GetHotspots())
+	}
+
+	clock.Advance(45 * time.Second)
+	if hotspots := ht.GetHotspots(); len(hotspots) != 1 || hotspots[0] != "new" {
+		t.Errorf("expected ['new'] after 'old' aged out, got %v", hotspots)
+	}
+}
+
+func TestWindowRemoveKey(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := newWindowedTracker(clock, 2, 1, time.Minute)
+
+	ht.RecordRequestN("a", 5)
+	ht.RemoveKey("a")
+	clock.Advance(30 * time.Second)
+	ht.RecordRequestN("a", 2)
+
+	// The removed requests must not be subtracted from the new ones
+	clock.Advance(45 * time.Second)
+	if freq, _ := ht.GetFrequency("a"); freq != 2 {
+		t.Errorf("expected 'a' to have frequency 2, got %d", freq)
+	}
+}
+
+func TestGetHotspotsAt(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ht := newWindowedTracker(clock, 2, 2, time.Minute) // one second buckets
+
+	ht.RecordRequestN("a", 5)
+	clock.Advance(time.Second)
+	ht.RecordRequestN("b", 3)
+	clock.Advance(time.Second)
+	ht.RecordRequest("a")
+	ht.RecordRequestN("c", 2)
+	ht.RecordRequestN("d", 1)
+
+	for ago, expected := range map[int][]KeyFreq{
+		0: {{Key: "c", Frequency: 2}, {Key: "a", Frequency: 1}},
+		1: {{Key: "b", Frequency: 3}},
+		2: {{Key: "a", Frequency: 5}},
+		3: {},
+	} {
+		got, err := ht.GetHotspotsAt(ago)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertKeyFreqs(t, got, expected)
+	}
+
+	// The oldest bucket still retained is the one "b" was recorded into
+	clock.Advance(58 * time.Second)
+	got, err := ht.GetHotspotsAt(windowBuckets - 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeyFreqs(t, got, []KeyFreq{{Key: "b", Frequency: 3}})
+
+	for _, ago := range []int{-1, windowBuckets} {
+		if _, err := ht.GetHotspotsAt(ago); !errors.Is(err, ErrBucketOutOfRange) {
+			t.Errorf("%d buckets ago: expected ErrBucketOutOfRange, got %v", ago, err)
+		}
+	}
+	if _, err := NewHotspotTracker(2, 2).GetHotspotsAt(0); !errors.Is(err, ErrWindowDisabled) {
+		t.Errorf("expected ErrWindowDisabled, got %v", err)
+	}
+}